@@ -19,12 +19,19 @@ package v1alpha1
 import (
 	"reflect"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+
+	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
 )
 
+// AnnotationKeyFieldPrefix prefixes annotations that set the CI field named
+// by the rest of their key, e.g. cmdb.ankasoft.co/field.support_group.
+const AnnotationKeyFieldPrefix = "cmdb.ankasoft.co/field."
+
 // ItemValue for observation
 /*type ItemValue struct {
 	Values
@@ -36,10 +43,148 @@ type CIParameters struct {
 	ClassName          string            `json:"className"`
 	Name               string            `json:"name"`
 	Values             map[string]string `json:"values,omitempty"`
+
+	// ValuesFrom sets CI values from the keys of Secrets and ConfigMaps. They
+	// take precedence over Values. Values read from Secrets are redacted from
+	// logs and events.
+	// +optional
+	ValuesFrom []ValueFromSource `json:"valuesFrom,omitempty"`
+
+	// ChangeControl requires an approved change request before the CI is
+	// updated. It overrides the change control of the ProviderConfig.
+	// +optional
+	ChangeControl *apisv1alpha1.ChangeControl `json:"changeControl,omitempty"`
+}
+
+// A ValueFromSource sets a CI value from a key of a Secret or a ConfigMap.
+// Exactly one of SecretKeyRef and ConfigMapKeyRef must be set.
+type ValueFromSource struct {
+	// Field of the CI the value is set to.
+	Field string `json:"field"`
+
+	// SecretKeyRef selects a key of a Secret.
+	// +optional
+	SecretKeyRef *xpv1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+
+	// ConfigMapKeyRef selects a key of a ConfigMap.
+	// +optional
+	ConfigMapKeyRef *ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// A ConfigMapKeySelector selects a key of a ConfigMap.
+type ConfigMapKeySelector struct {
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap.
+	Namespace string `json:"namespace"`
+
+	// Key whose value is selected.
+	Key string `json:"key"`
 }
 
 // CIObservation are the observable fields of Identification and Reconciliation API.
 type CIObservation struct {
+	// PlannedChange is the change the provider would make to the CI if it
+	// did not run in dry run mode, or its ProviderConfig was not read only.
+	// +optional
+	PlannedChange *PlannedChange `json:"plannedChange,omitempty"`
+
+	// ChangeRequest is the change request the pending update of the CI
+	// awaits.
+	// +optional
+	ChangeRequest *ChangeRequest `json:"changeRequest,omitempty"`
+}
+
+// A ChangeRequest that gates an update of a CI.
+type ChangeRequest struct {
+	// Number of the change request, e.g. CHG0030001.
+	Number string `json:"number"`
+
+	// SysID of the change request.
+	SysID string `json:"sysId"`
+
+	// State of the change request when it was last observed.
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Approval of the change request when it was last observed.
+	// +optional
+	Approval string `json:"approval,omitempty"`
+
+	// Fields of the CI the change request was created to update. A new
+	// change request is created if other fields are found to differ.
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+
+	// RequestedTime is the time the change request was created.
+	RequestedTime metav1.Time `json:"requestedTime"`
+}
+
+// A PlannedChange is a change to ServiceNow that was computed but not made.
+type PlannedChange struct {
+	// Operation that would be performed, either Create or Update.
+	Operation string `json:"operation"`
+
+	// Fields that would be changed by an Update.
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+
+	// Payload that would be sent to ServiceNow, with sensitive fields
+	// redacted.
+	// +optional
+	Payload string `json:"payload,omitempty"`
+
+	// PlannedTime is the time the change was first planned. It does not
+	// change while the same change remains planned.
+	PlannedTime metav1.Time `json:"plannedTime"`
+}
+
+// TypeChangeApproved indicates whether the pending update of a CI was
+// approved by a change request.
+const TypeChangeApproved xpv1.ConditionType = "ChangeApproved"
+
+// Reasons a CI's pending update is or is not approved.
+const (
+	ReasonAwaitingApproval xpv1.ConditionReason = "AwaitingApproval"
+	ReasonChangeApproved   xpv1.ConditionReason = "ChangeApproved"
+	ReasonChangeRejected   xpv1.ConditionReason = "ChangeRejected"
+)
+
+// AwaitingApproval returns a condition that indicates the pending update of a
+// CI awaits the approval of the supplied change request.
+func AwaitingApproval(number string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeChangeApproved,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAwaitingApproval,
+		Message:            "Change request " + number + " awaits approval",
+	}
+}
+
+// ChangeApproved returns a condition that indicates the update of a CI was
+// approved by the supplied change request.
+func ChangeApproved(number string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeChangeApproved,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonChangeApproved,
+		Message:            "Change request " + number + " was approved",
+	}
+}
+
+// ChangeRejected returns a condition that indicates the pending update of a
+// CI was rejected by the supplied change request.
+func ChangeRejected(number string) xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeChangeApproved,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonChangeRejected,
+		Message:            "Change request " + number + " was rejected or canceled",
+	}
 }
 
 // CISpec defines the desired state of Identification and Reconciliation API.
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+)
+
+// ClusterInventoryParameters are the configurable fields of a
+// ClusterInventory.
+type ClusterInventoryParameters struct {
+	// SysParamDataSource the CIs are reported by.
+	SysParamDataSource string `json:"sysParamDataSource"`
+
+	// ClusterName is the name of the cmdb_ci_kubernetes_cluster CI of the
+	// cluster the provider runs in. It qualifies the names of the CIs of the
+	// objects of the cluster.
+	ClusterName string `json:"clusterName"`
+
+	// NamespaceSelector selects the namespaces that are registered, along
+	// with their workloads and services. Every namespace is registered by
+	// default.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// InventoryCI is a CI registered for an object of the cluster, or for a
+// managed resource. Only what is needed to retire the CI is kept, so that
+// the status of large inventories stays small.
+type InventoryCI struct {
+	// ClassName of the CI.
+	ClassName string `json:"className"`
+
+	// Name of the CI.
+	Name string `json:"name"`
+}
+
+// InventoryObservation are the observable fields of a ClusterInventory or
+// ResourceInventory.
+type InventoryObservation struct {
+	// CIs registered for the objects of the cluster or the managed
+	// resources. CIs of objects that no longer exist are retired.
+	// +optional
+	CIs []InventoryCI `json:"cis,omitempty"`
+
+	// Hash of the most recently registered inventory.
+	// +optional
+	Hash string `json:"hash,omitempty"`
+
+	// RetiredTime is the time the CIs were retired after the inventory was
+	// deleted.
+	// +optional
+	RetiredTime *metav1.Time `json:"retiredTime,omitempty"`
+}
+
+// ClusterInventoryObservation are the observable fields of a
+// ClusterInventory.
+type ClusterInventoryObservation struct {
+	InventoryObservation `json:",inline"`
+}
+
+// ClusterInventorySpec defines the desired state of a ClusterInventory.
+type ClusterInventorySpec struct {
+	xpv1.ResourceSpec `json:",inline"`
+	ForProvider       ClusterInventoryParameters `json:"forProvider"`
+}
+
+// ClusterInventoryStatus represents the observed state of a ClusterInventory.
+type ClusterInventoryStatus struct {
+	xpv1.ResourceStatus `json:",inline"`
+	AtProvider          ClusterInventoryObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A ClusterInventory registers the cluster the provider runs in, its nodes,
+// namespaces, workloads and services, and their relations as CIs.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="CLUSTER",type="string",JSONPath=".spec.forProvider.clusterName"
+// +kubebuilder:printcolumn:name="EXTERNAL-NAME",type="string",JSONPath=".metadata.annotations.crossplane\\.io/external-name"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,cmdb}
+type ClusterInventory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterInventorySpec   `json:"spec"`
+	Status ClusterInventoryStatus `json:"status,omitempty"`
+}
+
+// GetSysParamDataSource returns the data source the CIs of the
+// ClusterInventory are reported by.
+func (in *ClusterInventory) GetSysParamDataSource() string {
+	return in.Spec.ForProvider.SysParamDataSource
+}
+
+// GetInventoryObservation returns the observed CIs of the ClusterInventory.
+func (in *ClusterInventory) GetInventoryObservation() *InventoryObservation {
+	return &in.Status.AtProvider.InventoryObservation
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterInventoryList contains a list of ClusterInventory
+type ClusterInventoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterInventory `json:"items"`
+}
+
+// ClusterInventory type metadata.
+var (
+	ClusterInventoryKind             = reflect.TypeOf(ClusterInventory{}).Name()
+	ClusterInventoryGroupKind        = schema.GroupKind{Group: Group, Kind: ClusterInventoryKind}.String()
+	ClusterInventoryKindAPIVersion   = ClusterInventoryKind + "." + SchemeGroupVersion.String()
+	ClusterInventoryGroupVersionKind = SchemeGroupVersion.WithKind(ClusterInventoryKind)
+)
+
+func init() {
+	SchemeBuilder.Register(&ClusterInventory{}, &ClusterInventoryList{})
+}
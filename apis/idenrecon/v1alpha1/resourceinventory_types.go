@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+)
+
+// A ResourceKind identifies a kind of managed resource.
+type ResourceKind struct {
+	// APIVersion of the managed resources, e.g. database.aws.crossplane.io/v1beta1.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the managed resources, e.g. RDSInstance.
+	Kind string `json:"kind"`
+}
+
+// A FieldMapping sets a value of a CI from a field of a managed resource.
+type FieldMapping struct {
+	// Field of the CI, e.g. ip_address.
+	Field string `json:"field"`
+
+	// FromFieldPath is the path of the field of the managed resource the value
+	// is read from, e.g. status.atProvider.endpoint.address. Values that are
+	// not strings are encoded as JSON. Fields the managed resource does not
+	// have are not set.
+	FromFieldPath string `json:"fromFieldPath"`
+}
+
+// ResourceInventoryParameters are the configurable fields of a
+// ResourceInventory.
+type ResourceInventoryParameters struct {
+	// SysParamDataSource the CIs are reported by.
+	SysParamDataSource string `json:"sysParamDataSource"`
+
+	// Resources are the kinds of managed resources that are registered. The
+	// provider must be allowed to get, list and watch them.
+	// +kubebuilder:validation:MinItems=1
+	Resources []ResourceKind `json:"resources"`
+
+	// Selector selects the managed resources that are registered. Every
+	// managed resource of the kinds is registered by default.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// ClassName of the CIs, e.g. cmdb_ci_db_instance.
+	ClassName string `json:"className"`
+
+	// NameFieldPath is the path of the field of a managed resource the name
+	// of its CI is read from. Defaults to metadata.name.
+	// +optional
+	NameFieldPath *string `json:"nameFieldPath,omitempty"`
+
+	// Fields of the CIs that are set from the fields of the managed resources.
+	// The correlation_id of a CI is always set to the external name of its
+	// managed resource.
+	// +optional
+	Fields []FieldMapping `json:"fields,omitempty"`
+}
+
+// ResourceInventoryObservation are the observable fields of a
+// ResourceInventory.
+type ResourceInventoryObservation struct {
+	InventoryObservation `json:",inline"`
+}
+
+// ResourceInventorySpec defines the desired state of a ResourceInventory.
+type ResourceInventorySpec struct {
+	xpv1.ResourceSpec `json:",inline"`
+	ForProvider       ResourceInventoryParameters `json:"forProvider"`
+}
+
+// ResourceInventoryStatus represents the observed state of a
+// ResourceInventory.
+type ResourceInventoryStatus struct {
+	xpv1.ResourceStatus `json:",inline"`
+	AtProvider          ResourceInventoryObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A ResourceInventory registers the managed resources of other providers, such
+// as cloud databases and virtual machines, as CIs of a class. Managed
+// resources are polled rather than watched, so changes to them are registered
+// within the poll interval.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="CLASS",type="string",JSONPath=".spec.forProvider.className"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,cmdb}
+type ResourceInventory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ResourceInventorySpec   `json:"spec"`
+	Status ResourceInventoryStatus `json:"status,omitempty"`
+}
+
+// GetSysParamDataSource returns the data source the CIs of the
+// ResourceInventory are reported by.
+func (in *ResourceInventory) GetSysParamDataSource() string {
+	return in.Spec.ForProvider.SysParamDataSource
+}
+
+// GetInventoryObservation returns the observed CIs of the ResourceInventory.
+func (in *ResourceInventory) GetInventoryObservation() *InventoryObservation {
+	return &in.Status.AtProvider.InventoryObservation
+}
+
+// +kubebuilder:object:root=true
+
+// ResourceInventoryList contains a list of ResourceInventory
+type ResourceInventoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ResourceInventory `json:"items"`
+}
+
+// ResourceInventory type metadata.
+var (
+	ResourceInventoryKind             = reflect.TypeOf(ResourceInventory{}).Name()
+	ResourceInventoryGroupKind        = schema.GroupKind{Group: Group, Kind: ResourceInventoryKind}.String()
+	ResourceInventoryKindAPIVersion   = ResourceInventoryKind + "." + SchemeGroupVersion.String()
+	ResourceInventoryGroupVersionKind = SchemeGroupVersion.WithKind(ResourceInventoryKind)
+)
+
+func init() {
+	SchemeBuilder.Register(&ResourceInventory{}, &ResourceInventoryList{})
+}
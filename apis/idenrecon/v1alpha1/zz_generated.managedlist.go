@@ -27,3 +27,21 @@ func (l *CIList) GetItems() []resource.Managed {
 	}
 	return items
 }
+
+// GetItems of this ClusterInventoryList.
+func (l *ClusterInventoryList) GetItems() []resource.Managed {
+	items := make([]resource.Managed, len(l.Items))
+	for i := range l.Items {
+		items[i] = &l.Items[i]
+	}
+	return items
+}
+
+// GetItems of this ResourceInventoryList.
+func (l *ResourceInventoryList) GetItems() []resource.Managed {
+	items := make([]resource.Managed, len(l.Items))
+	for i := range l.Items {
+		items[i] = &l.Items[i]
+	}
+	return items
+}
@@ -25,14 +25,109 @@ import (
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 )
 
+// A QueryTerm compares a field of the records with values.
+type QueryTerm struct {
+	// Field of the records, e.g. name or a dot-walked field such as
+	// location.city.
+	Field string `json:"field"`
+
+	// Operator the field is compared with. Defaults to =.
+	// +kubebuilder:validation:Enum="=";"!=";"<";"<=";">";">=";STARTSWITH;ENDSWITH;LIKE;NOTLIKE;IN;"NOT IN";ISEMPTY;ISNOTEMPTY
+	// +optional
+	Operator string `json:"operator,omitempty"`
+
+	// Value the field is compared with. ISEMPTY and ISNOTEMPTY take no
+	// value.
+	// +optional
+	Value *string `json:"value,omitempty"`
+
+	// Values the field is compared with by IN and NOT IN. They must not
+	// contain commas.
+	// +optional
+	Values []string `json:"values,omitempty"`
+}
+
+// A QueryCondition the records must match.
+type QueryCondition struct {
+	QueryTerm `json:",inline"`
+
+	// Or are alternatives to the condition, any of which the records may
+	// match instead.
+	// +optional
+	Or []QueryTerm `json:"or,omitempty"`
+}
+
+// A QueryOrder orders the records by a field.
+type QueryOrder struct {
+	// Field the records are ordered by.
+	Field string `json:"field"`
+
+	// Descending orders the records from the highest value to the lowest.
+	// +optional
+	Descending bool `json:"descending,omitempty"`
+}
+
+// A StructuredQuery is an alternative to an encoded query, whose values are
+// escaped when it is encoded.
+type StructuredQuery struct {
+	// Conditions the records must all match.
+	// +optional
+	Conditions []QueryCondition `json:"conditions,omitempty"`
+
+	// OrderBy orders the records by the supplied fields.
+	// +optional
+	OrderBy []QueryOrder `json:"orderBy,omitempty"`
+}
+
 // TableParameters are the configurable fields of Table API.
 type TableParameters struct {
+	// TableName that is queried, e.g. cmn_location.
 	TableName string `json:"tableName"`
-	Query     string `json:"query"`
+
+	// Query is the encoded query the records must match, e.g.
+	// name=Istanbul. Every record matches if neither it nor a
+	// structuredQuery is supplied. Records are ordered by sys_id unless the
+	// query orders them.
+	// +optional
+	Query string `json:"query,omitempty"`
+
+	// StructuredQuery the records must match, instead of an encoded query.
+	// +optional
+	StructuredQuery *StructuredQuery `json:"structuredQuery,omitempty"`
+
+	// Fields of the records that are reported in the status and connection
+	// details. Only the sys_ids of the records are reported by default. The
+	// fields of at most the first 10 records are reported in the status.
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+
+	// Limit is the number of records that are read, at most 1000. The first
+	// 100 records that match the query are read by default.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	Limit *int `json:"limit,omitempty"`
+
+	// DisplayValue reports the display values of the selected fields instead
+	// of their values, e.g. the name of the record a reference field refers
+	// to instead of its sys_id.
+	// +optional
+	DisplayValue bool `json:"displayValue,omitempty"`
 }
 
 // TableObservation are the observable fields of Table API.
 type TableObservation struct {
+	// Count of the records that were read, up to the limit.
+	Count int `json:"count"`
+
+	// SysIDs of the records that were read.
+	// +optional
+	SysIDs []string `json:"sysIds,omitempty"`
+
+	// Records that were read, as the values of their selected fields. Only
+	// the first 10 records are reported.
+	// +optional
+	Records []map[string]string `json:"records,omitempty"`
 }
 
 // TableSpec defines the desired state of Table API.
@@ -49,10 +144,17 @@ type TableStatus struct {
 
 // +kubebuilder:object:root=true
 
-// A Table is Table API type.
+// A Table is a read only query of a table. The query is run periodically and
+// its results are reported in the status and, if a connection secret is
+// requested, as connection details: the count of the records, and the sys_id
+// and selected fields of the first record in the order of the query, or of
+// sys_ids if the query has none. It is Ready once a record matches the query,
+// so that compositions can look up e.g. the sys_id of a location or group by
+// name.
 // +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
 // +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
-// +kubebuilder:printcolumn:name="EXTERNAL-NAME",type="string",JSONPath=".metadata.annotations.crossplane\\.io/external-name"
+// +kubebuilder:printcolumn:name="TABLE",type="string",JSONPath=".spec.forProvider.tableName"
+// +kubebuilder:printcolumn:name="COUNT",type="integer",JSONPath=".status.atProvider.count"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,cmdb}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+)
+
+// TableRecordSetParameters are the configurable fields of a TableRecordSet.
+type TableRecordSetParameters struct {
+	// TableName of the records, e.g. sys_choice.
+	TableName string `json:"tableName"`
+
+	// Query is an encoded query that scopes the records of the table the set
+	// is kept in sync with, e.g. name=incident^element=category. Records must
+	// match it once they are created: a record that does not is deleted
+	// again, and reported as an error. Every record of the table is in scope
+	// by default.
+	// +optional
+	Query string `json:"query,omitempty"`
+
+	// KeyField identifies the records of the set within the scope, e.g.
+	// value. Every record must have a value for it.
+	KeyField string `json:"keyField"`
+
+	// Records of the set, as the values of their fields.
+	Records []map[string]string `json:"records"`
+
+	// Prune deletes the records in scope that are not in the set. It requires
+	// a query, so that not every other record of the table is deleted.
+	// +optional
+	Prune bool `json:"prune,omitempty"`
+}
+
+// A TableRecord is a record of a TableRecordSet.
+type TableRecord struct {
+	// Key of the record.
+	Key string `json:"key"`
+
+	// SysID of the record.
+	SysID string `json:"sysId"`
+}
+
+// A TableRecordSetChange is a change to the records of a TableRecordSet that
+// was computed but not made.
+type TableRecordSetChange struct {
+	// Create are the keys of the records that would be created.
+	// +optional
+	Create []string `json:"create,omitempty"`
+
+	// Update are the keys of the records that would be updated.
+	// +optional
+	Update []string `json:"update,omitempty"`
+
+	// Delete are the keys of the records that would be pruned.
+	// +optional
+	Delete []string `json:"delete,omitempty"`
+
+	// PlannedTime is the time the change was planned.
+	PlannedTime metav1.Time `json:"plannedTime"`
+}
+
+// TableRecordSetObservation are the observable fields of a TableRecordSet.
+type TableRecordSetObservation struct {
+	// Records of the set that exist in the table.
+	// +optional
+	Records []TableRecord `json:"records,omitempty"`
+
+	// PlannedChange is the change the provider would make to the table if
+	// changes to ServiceNow were enabled.
+	// +optional
+	PlannedChange *TableRecordSetChange `json:"plannedChange,omitempty"`
+}
+
+// TableRecordSetSpec defines the desired state of a TableRecordSet.
+type TableRecordSetSpec struct {
+	xpv1.ResourceSpec `json:",inline"`
+	ForProvider       TableRecordSetParameters `json:"forProvider"`
+}
+
+// TableRecordSetStatus represents the observed state of a TableRecordSet.
+type TableRecordSetStatus struct {
+	xpv1.ResourceStatus `json:",inline"`
+	AtProvider          TableRecordSetObservation `json:"atProvider,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// A TableRecordSet keeps a set of records of a table, such as a choice list
+// or a lookup table, in sync. Missing records are created, changed records
+// are updated and, optionally, undeclared records are deleted.
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="TABLE",type="string",JSONPath=".spec.forProvider.tableName"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories={crossplane,managed,cmdb}
+type TableRecordSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TableRecordSetSpec   `json:"spec"`
+	Status TableRecordSetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TableRecordSetList contains a list of TableRecordSet
+type TableRecordSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TableRecordSet `json:"items"`
+}
+
+// TableRecordSet type metadata.
+var (
+	TableRecordSetKind             = reflect.TypeOf(TableRecordSet{}).Name()
+	TableRecordSetGroupKind        = schema.GroupKind{Group: Group, Kind: TableRecordSetKind}.String()
+	TableRecordSetKindAPIVersion   = TableRecordSetKind + "." + SchemeGroupVersion.String()
+	TableRecordSetGroupVersionKind = SchemeGroupVersion.WithKind(TableRecordSetKind)
+)
+
+func init() {
+	SchemeBuilder.Register(&TableRecordSet{}, &TableRecordSetList{})
+}
@@ -25,6 +25,96 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryCondition) DeepCopyInto(out *QueryCondition) {
+	*out = *in
+	in.QueryTerm.DeepCopyInto(&out.QueryTerm)
+	if in.Or != nil {
+		in, out := &in.Or, &out.Or
+		*out = make([]QueryTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryCondition.
+func (in *QueryCondition) DeepCopy() *QueryCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryOrder) DeepCopyInto(out *QueryOrder) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryOrder.
+func (in *QueryOrder) DeepCopy() *QueryOrder {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryOrder)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryTerm) DeepCopyInto(out *QueryTerm) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryTerm.
+func (in *QueryTerm) DeepCopy() *QueryTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StructuredQuery) DeepCopyInto(out *StructuredQuery) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]QueryCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OrderBy != nil {
+		in, out := &in.OrderBy, &out.OrderBy
+		*out = make([]QueryOrder, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StructuredQuery.
+func (in *StructuredQuery) DeepCopy() *StructuredQuery {
+	if in == nil {
+		return nil
+	}
+	out := new(StructuredQuery)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Table) DeepCopyInto(out *Table) {
 	*out = *in
@@ -87,6 +177,24 @@ func (in *TableList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TableObservation) DeepCopyInto(out *TableObservation) {
 	*out = *in
+	if in.SysIDs != nil {
+		in, out := &in.SysIDs, &out.SysIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Records != nil {
+		in, out := &in.Records, &out.Records
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableObservation.
@@ -102,6 +210,21 @@ func (in *TableObservation) DeepCopy() *TableObservation {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TableParameters) DeepCopyInto(out *TableParameters) {
 	*out = *in
+	if in.StructuredQuery != nil {
+		in, out := &in.StructuredQuery, &out.StructuredQuery
+		*out = new(StructuredQuery)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Limit != nil {
+		in, out := &in.Limit, &out.Limit
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableParameters.
@@ -114,11 +237,203 @@ func (in *TableParameters) DeepCopy() *TableParameters {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableRecord) DeepCopyInto(out *TableRecord) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableRecord.
+func (in *TableRecord) DeepCopy() *TableRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(TableRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableRecordSet) DeepCopyInto(out *TableRecordSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableRecordSet.
+func (in *TableRecordSet) DeepCopy() *TableRecordSet {
+	if in == nil {
+		return nil
+	}
+	out := new(TableRecordSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TableRecordSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableRecordSetChange) DeepCopyInto(out *TableRecordSetChange) {
+	*out = *in
+	if in.Create != nil {
+		in, out := &in.Create, &out.Create
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Update != nil {
+		in, out := &in.Update, &out.Update
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Delete != nil {
+		in, out := &in.Delete, &out.Delete
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.PlannedTime.DeepCopyInto(&out.PlannedTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableRecordSetChange.
+func (in *TableRecordSetChange) DeepCopy() *TableRecordSetChange {
+	if in == nil {
+		return nil
+	}
+	out := new(TableRecordSetChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableRecordSetList) DeepCopyInto(out *TableRecordSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TableRecordSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableRecordSetList.
+func (in *TableRecordSetList) DeepCopy() *TableRecordSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(TableRecordSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TableRecordSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableRecordSetObservation) DeepCopyInto(out *TableRecordSetObservation) {
+	*out = *in
+	if in.Records != nil {
+		in, out := &in.Records, &out.Records
+		*out = make([]TableRecord, len(*in))
+		copy(*out, *in)
+	}
+	if in.PlannedChange != nil {
+		in, out := &in.PlannedChange, &out.PlannedChange
+		*out = new(TableRecordSetChange)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableRecordSetObservation.
+func (in *TableRecordSetObservation) DeepCopy() *TableRecordSetObservation {
+	if in == nil {
+		return nil
+	}
+	out := new(TableRecordSetObservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableRecordSetParameters) DeepCopyInto(out *TableRecordSetParameters) {
+	*out = *in
+	if in.Records != nil {
+		in, out := &in.Records, &out.Records
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableRecordSetParameters.
+func (in *TableRecordSetParameters) DeepCopy() *TableRecordSetParameters {
+	if in == nil {
+		return nil
+	}
+	out := new(TableRecordSetParameters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableRecordSetSpec) DeepCopyInto(out *TableRecordSetSpec) {
+	*out = *in
+	in.ResourceSpec.DeepCopyInto(&out.ResourceSpec)
+	in.ForProvider.DeepCopyInto(&out.ForProvider)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableRecordSetSpec.
+func (in *TableRecordSetSpec) DeepCopy() *TableRecordSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TableRecordSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableRecordSetStatus) DeepCopyInto(out *TableRecordSetStatus) {
+	*out = *in
+	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
+	in.AtProvider.DeepCopyInto(&out.AtProvider)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableRecordSetStatus.
+func (in *TableRecordSetStatus) DeepCopy() *TableRecordSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TableRecordSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TableSpec) DeepCopyInto(out *TableSpec) {
 	*out = *in
 	in.ResourceSpec.DeepCopyInto(&out.ResourceSpec)
-	out.ForProvider = in.ForProvider
+	in.ForProvider.DeepCopyInto(&out.ForProvider)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableSpec.
@@ -135,7 +450,7 @@ func (in *TableSpec) DeepCopy() *TableSpec {
 func (in *TableStatus) DeepCopyInto(out *TableStatus) {
 	*out = *in
 	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
-	out.AtProvider = in.AtProvider
+	in.AtProvider.DeepCopyInto(&out.AtProvider)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableStatus.
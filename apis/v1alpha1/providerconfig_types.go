@@ -18,7 +18,9 @@ package v1alpha1
 
 import (
 	"reflect"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -35,6 +37,200 @@ type ProviderConfigSpec struct {
 
 	// Credentials required to authenticate to this provider.
 	Credentials ProviderCredentials `json:"credentials"`
+
+	// RateLimit of the requests sent to the ServiceNow instance. Requests are
+	// not limited by default, but rate limited responses are always honoured.
+	// +optional
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+
+	// RequestTimeout bounds each call to a ServiceNow API, including its
+	// retries. Defaults to 30s.
+	// +optional
+	RequestTimeout *metav1.Duration `json:"requestTimeout,omitempty"`
+
+	// ReadOnly prevents any change to the ServiceNow instance. Resources
+	// using the ProviderConfig are observed, and the changes that would be
+	// made to them are logged, recorded as events and in their status.
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// FieldsFrom sets CI values from the labels and annotations of the CIs
+	// using the ProviderConfig. Values set by a CI take precedence.
+	// +optional
+	FieldsFrom *FieldsFrom `json:"fieldsFrom,omitempty"`
+
+	// ChangeControl requires an approved change request before CIs using the
+	// ProviderConfig are updated. CIs may override it.
+	// +optional
+	ChangeControl *ChangeControl `json:"changeControl,omitempty"`
+
+	// Schedules restrict the times at which resources using the
+	// ProviderConfig make changes to ServiceNow. Changes are deferred until
+	// they are allowed, and may be made at any time by default.
+	// +optional
+	Schedules *Schedules `json:"schedules,omitempty"`
+
+	// Debug configures diagnostics of the requests sent to the ServiceNow
+	// instance.
+	// +optional
+	Debug *Debug `json:"debug,omitempty"`
+}
+
+// Schedules of the windows in which changes may be made to ServiceNow.
+type Schedules struct {
+	// Allow windows in which changes may be made. Changes may be made at any
+	// time if there are none and no schedule is referenced.
+	// +optional
+	Allow []Window `json:"allow,omitempty"`
+
+	// Deny windows in which changes may not be made, such as business
+	// critical periods. They take precedence over allow windows.
+	// +optional
+	Deny []Window `json:"deny,omitempty"`
+
+	// ScheduleSysID is the sys_id of a cmn_schedule of the instance. Changes
+	// may only be made while one of its entries is in effect, and not while
+	// one of its excluded entries is. Entries that repeat daily, on weekdays,
+	// on weekends or weekly are supported.
+	// +optional
+	ScheduleSysID *string `json:"scheduleSysId,omitempty"`
+}
+
+// A Window of time that opens on a cron schedule.
+type Window struct {
+	// Start of the window as a cron expression with five fields: minute,
+	// hour, day of month, month and day of week, e.g. "0 22 * * 1-5" for
+	// 22:00 on weekdays.
+	Start string `json:"start"`
+
+	// Duration of the window, e.g. 4h.
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone of the start of the window, e.g. Europe/Istanbul. Defaults to
+	// UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// TypeWriteWindow indicates whether changes to the external resource of a
+// managed resource may be made, according to the schedules of its
+// ProviderConfig.
+const TypeWriteWindow xpv1.ConditionType = "WriteWindow"
+
+// Reasons changes may or may not be made.
+const (
+	ReasonWindowOpen   xpv1.ConditionReason = "WindowOpen"
+	ReasonWindowClosed xpv1.ConditionReason = "WindowClosed"
+)
+
+// WindowOpen returns a condition that indicates changes may be made.
+func WindowOpen() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               TypeWriteWindow,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonWindowOpen,
+	}
+}
+
+// WindowClosed returns a condition that indicates changes are deferred until
+// the supplied time, or indefinitely if it is zero.
+func WindowClosed(opens time.Time) xpv1.Condition {
+	msg := "Changes are deferred until the write window opens"
+	if !opens.IsZero() {
+		msg = "Changes are deferred until " + opens.UTC().Format(time.RFC3339)
+	}
+	return xpv1.Condition{
+		Type:               TypeWriteWindow,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonWindowClosed,
+		Message:            msg,
+	}
+}
+
+// A ChangeControlPolicy determines whether CIs are updated without a change
+// request.
+type ChangeControlPolicy string
+
+// Change control policies.
+const (
+	// ChangeControlRequired CIs are only updated once a change request for
+	// the update is approved.
+	ChangeControlRequired ChangeControlPolicy = "Required"
+
+	// ChangeControlNone CIs are updated without a change request.
+	ChangeControlNone ChangeControlPolicy = "None"
+)
+
+// ChangeControl configures the change requests that gate updates to existing
+// CIs. A change request is created in the change_request table when a CI is
+// found to differ from its desired values, and the CI is updated once the
+// change request reaches an approved state. CIs are created without one.
+type ChangeControl struct {
+	// Policy of the change control. Defaults to Required.
+	// +kubebuilder:validation:Enum=Required;None
+	// +optional
+	Policy ChangeControlPolicy `json:"policy,omitempty"`
+
+	// Values of the change requests that are created, e.g. assignment_group
+	// or category. Their short_description and description are set by the
+	// provider.
+	// +optional
+	Values map[string]string `json:"values,omitempty"`
+
+	// ApprovedStates are the values of the state field of change requests in
+	// which CIs may be updated. Defaults to -2 (Scheduled) and -1
+	// (Implement).
+	// +optional
+	ApprovedStates []string `json:"approvedStates,omitempty"`
+}
+
+// Enabled returns true if the change control requires change requests.
+func (c *ChangeControl) Enabled() bool {
+	return c != nil && c.Policy != ChangeControlNone
+}
+
+// FieldsFrom maps the labels and annotations of CIs to CI fields.
+type FieldsFrom struct {
+	// Labels maps label keys to the CI fields their values are set to, e.g.
+	// team.example.org/owner: support_group.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations maps annotation keys to the CI fields their values are set
+	// to. Annotations prefixed with cmdb.ankasoft.co/field. always set the
+	// field named by the rest of their key.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Debug configures diagnostics of the requests sent to a ServiceNow instance.
+type Debug struct {
+	// LogRequests logs every request sent to the instance and its response,
+	// even if the provider does not run with --debug. Credentials and tokens
+	// are always redacted.
+	// +optional
+	LogRequests bool `json:"logRequests,omitempty"`
+
+	// RedactFields are CI fields whose values are redacted from logged
+	// requests and responses.
+	// +optional
+	RedactFields []string `json:"redactFields,omitempty"`
+}
+
+// RateLimit configures a token bucket shared by every request sent to a
+// ServiceNow instance.
+type RateLimit struct {
+	// RequestsPerSecond that may be sent to the instance.
+	// +kubebuilder:validation:Minimum=1
+	RequestsPerSecond int `json:"requestsPerSecond"`
+
+	// Burst of requests that may be sent at once. Defaults to
+	// RequestsPerSecond.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Burst *int `json:"burst,omitempty"`
 }
 
 // ProviderCredentials required to authenticate.
@@ -49,12 +245,30 @@ type ProviderCredentials struct {
 // A ProviderConfigStatus reflects the observed state of a ProviderConfig.
 type ProviderConfigStatus struct {
 	xpv1.ProviderConfigStatus `json:",inline"`
+
+	// Instance reflects the ServiceNow instance observed by the most recent
+	// successful connectivity probe.
+	// +optional
+	Instance InstanceObservation `json:"instance,omitempty"`
+}
+
+// InstanceObservation are the observable fields of a ServiceNow instance.
+type InstanceObservation struct {
+	// Version is the release family of the instance, e.g. Tokyo.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Build is the build tag of the instance.
+	// +optional
+	Build string `json:"build,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 
 // A ProviderConfig configures a CMDB provider.
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="VERSION",type="string",JSONPath=".status.instance.version"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:printcolumn:name="SECRET-NAME",type="string",JSONPath=".spec.credentials.secretRef.name",priority=1
 // +kubebuilder:resource:scope=Cluster
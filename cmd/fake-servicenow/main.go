@@ -0,0 +1,44 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command fake-servicenow serves an in-memory fake ServiceNow instance, so
+// that the provider can be run locally without one.
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+func main() {
+	var (
+		app      = kingpin.New(filepath.Base(os.Args[0]), "In-memory fake of the ServiceNow REST APIs used by provider-cmdb.").DefaultEnvars()
+		address  = app.Flag("address", "Address to serve the fake instance on.").Default(":8080").String()
+		username = app.Flag("username", "Username the fake instance accepts.").Default(servicenow.DefaultUsername).String()
+		password = app.Flag("password", "Password the fake instance accepts.").Default(servicenow.DefaultPassword).String()
+	)
+	kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	i := servicenow.NewInstance(servicenow.WithCredentials(*username, *password))
+	s := &http.Server{Addr: *address, Handler: i, ReadHeaderTimeout: 10 * time.Second}
+	kingpin.FatalIfError(s.ListenAndServe(), "Cannot serve fake ServiceNow instance")
+}
@@ -38,8 +38,10 @@ import (
 
 	"github.com/crossplane/provider-cmdb/apis"
 	"github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
 	cmdb "github.com/crossplane/provider-cmdb/internal/controller"
 	"github.com/crossplane/provider-cmdb/internal/controller/features"
+	"github.com/crossplane/provider-cmdb/internal/tracing"
 )
 
 func main() {
@@ -54,6 +56,12 @@ func main() {
 
 		namespace                  = app.Flag("namespace", "Namespace used to set as default scope in default secret store config.").Default("crossplane-system").Envar("POD_NAMESPACE").String()
 		enableExternalSecretStores = app.Flag("enable-external-secret-stores", "Enable support for ExternalSecretStores.").Default("false").Envar("ENABLE_EXTERNAL_SECRET_STORES").Bool()
+
+		dryRun = app.Flag("dry-run", "Observe ServiceNow without changing it. Changes that would be made are logged, recorded as events and in the status of resources.").Default("false").Envar("DRY_RUN").Bool()
+
+		otlpEndpoint     = app.Flag("otlp-endpoint", "The OTLP gRPC endpoint traces are exported to. Tracing is disabled if it is empty.").Envar("OTEL_EXPORTER_OTLP_ENDPOINT").String()
+		otlpInsecure     = app.Flag("otlp-insecure", "Connect to the OTLP endpoint without TLS.").Default("false").Envar("OTEL_EXPORTER_OTLP_INSECURE").Bool()
+		traceSampleRatio = app.Flag("trace-sample-ratio", "The fraction of reconciles that are traced.").Default("1").Float64()
 	)
 	kingpin.MustParse(app.Parse(os.Args[1:]))
 
@@ -66,6 +74,18 @@ func main() {
 		ctrl.SetLogger(zl)
 	}
 
+	clients.SetWireLogger(log.WithValues("component", "servicenow-client"), *debug)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{Endpoint: *otlpEndpoint, Insecure: *otlpInsecure, SampleRatio: *traceSampleRatio})
+	kingpin.FatalIfError(err, "Cannot set up tracing")
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Info("Cannot flush traces", "error", err)
+		}
+	}()
+
 	cfg, err := ctrl.GetConfig()
 	kingpin.FatalIfError(err, "Cannot get API server rest config")
 
@@ -96,6 +116,11 @@ func main() {
 		Features:                &feature.Flags{},
 	}
 
+	if *dryRun {
+		o.Features.Enable(features.DryRun)
+		log.Info("Dry run enabled, no changes will be made to ServiceNow")
+	}
+
 	if *enableExternalSecretStores {
 		o.Features.Enable(features.EnableAlphaExternalSecretStores)
 		log.Info("Alpha feature enabled", "flag", features.EnableAlphaExternalSecretStores)
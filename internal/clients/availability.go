@@ -0,0 +1,207 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+)
+
+// Reasons a ServiceNow instance does not serve its REST APIs.
+const (
+	ReasonHibernating        xpv1.ConditionReason = "Hibernating"
+	ReasonSSORedirect        xpv1.ConditionReason = "SSORedirect"
+	ReasonMaintenance        xpv1.ConditionReason = "Maintenance"
+	ReasonUnexpectedResponse xpv1.ConditionReason = "UnexpectedResponse"
+)
+
+// maxPeekedBody is how much of an HTML response is read to classify it.
+const maxPeekedBody = 64 << 10
+
+// unavailableBackoff is how long requests to an unavailable instance are
+// held back. Waking a hibernating developer instance takes minutes, and a
+// login page will not go away until somebody changes the instance. Requests
+// are not held back for responses that are merely unexpected.
+var unavailableBackoff = map[xpv1.ConditionReason]time.Duration{
+	ReasonHibernating: 5 * time.Minute,
+	ReasonSSORedirect: 5 * time.Minute,
+	ReasonMaintenance: 2 * time.Minute,
+}
+
+// loginPaths are the pages ServiceNow redirects to when a request has to
+// sign in interactively.
+var loginPaths = []string{"/login.do", "/login_with_sso.do", "/login_locate_sso.do", "/saml_redirector.do", "/multisso_redirector.do"}
+
+// Markers of the pages of a hibernating instance and of the login page.
+var (
+	hibernatingMarkers = [][]byte{[]byte("instance hibernating"), []byte("instance is hibernating")}
+	loginMarkers       = [][]byte{[]byte(`action="login.do"`), []byte(`name="user_password"`)}
+)
+
+// An UnavailableError is returned when a ServiceNow instance responds with
+// a redirect or an HTML page rather than a REST API response.
+type UnavailableError struct {
+	Reason     xpv1.ConditionReason
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	var msg string
+	switch e.Reason {
+	case ReasonHibernating:
+		msg = "ServiceNow instance is hibernating, wake it up from the ServiceNow developer portal"
+	case ReasonSSORedirect:
+		msg = "ServiceNow redirected the request to a login page, check that the configured user may use basic authentication"
+	case ReasonMaintenance:
+		msg = "ServiceNow instance is down for maintenance"
+	default:
+		msg = fmt.Sprintf("ServiceNow responded with %d %s instead of a REST API response", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if e.RetryAfter <= 0 {
+		return msg
+	}
+	return fmt.Sprintf("%s, retry after %s", msg, e.RetryAfter.Round(time.Second))
+}
+
+// Condition returns an Unavailable condition that describes why the instance
+// is unavailable.
+func (e *UnavailableError) Condition() xpv1.Condition {
+	c := xpv1.Unavailable().WithMessage(e.Error())
+	c.Reason = e.Reason
+	return c
+}
+
+// AsUnavailable returns the UnavailableError the supplied error was caused
+// by, if any.
+func AsUnavailable(err error) (*UnavailableError, bool) {
+	var unavailable *UnavailableError
+	ok := errors.As(err, &unavailable)
+	return unavailable, ok
+}
+
+// An availabilityTransport recognises responses of an instance that is not
+// serving its REST APIs, and holds back requests to it for a while.
+type availabilityTransport struct {
+	limiter *instanceLimiter
+	next    http.RoundTripper
+}
+
+// RoundTrip sends the request and returns an UnavailableError instead of
+// redirects and HTML pages.
+func (t *availabilityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+
+	reason, err := classifyResponse(res)
+	if err != nil || reason == "" {
+		return res, err
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+
+	e := &UnavailableError{Reason: reason, StatusCode: res.StatusCode, RetryAfter: unavailableBackoff[reason]}
+	if e.RetryAfter > 0 {
+		t.limiter.suspend(e)
+	}
+	return nil, e
+}
+
+// classifyResponse returns why the supplied response is not a REST API
+// response, or an empty reason if it is one. Only redirects to a login page
+// are classified; other redirects are left to the caller. The body of HTML
+// responses is peeked at, leaving it readable.
+func classifyResponse(res *http.Response) (xpv1.ConditionReason, error) {
+	if res.StatusCode >= http.StatusMultipleChoices && res.StatusCode < http.StatusBadRequest {
+		if isLoginRedirect(res.Header.Get("Location")) {
+			return ReasonSSORedirect, nil
+		}
+		return "", nil
+	}
+
+	mt, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mt != "text/html" {
+		return "", nil
+	}
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, maxPeekedBody))
+	if err != nil {
+		return "", err
+	}
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{Reader: io.MultiReader(bytes.NewReader(b), res.Body), Closer: res.Body}
+
+	page := bytes.ToLower(b)
+	switch {
+	case containsAny(page, hibernatingMarkers):
+		return ReasonHibernating, nil
+	case res.StatusCode == http.StatusServiceUnavailable:
+		return ReasonMaintenance, nil
+	case containsAny(page, loginMarkers):
+		return ReasonSSORedirect, nil
+	}
+	return ReasonUnexpectedResponse, nil
+}
+
+// isLoginRedirect returns true if the supplied Location points to a login
+// page of the instance or to a SAML identity provider.
+func isLoginRedirect(location string) bool {
+	u, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	if u.Query().Get("SAMLRequest") != "" {
+		return true
+	}
+	path := strings.ToLower(u.Path)
+	for _, p := range loginPaths {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny returns true if the supplied page contains any of the markers.
+func containsAny(page []byte, markers [][]byte) bool {
+	for _, m := range markers {
+		if bytes.Contains(page, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// noRedirect stops the HTTP client from following redirects, which the REST
+// APIs never respond with, so that they can be classified.
+func noRedirect(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
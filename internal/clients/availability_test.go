@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/time/rate"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+)
+
+func response(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func html(status int, body string) *http.Response {
+	return response(status, http.Header{"Content-Type": {"text/html; charset=UTF-8"}}, body)
+}
+
+func TestClassifyResponse(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		res    *http.Response
+		want   xpv1.ConditionReason
+	}{
+		"JSON": {
+			reason: "A REST API response should not be classified.",
+			res:    response(http.StatusOK, http.Header{"Content-Type": {"application/json"}}, `{"result":[]}`),
+		},
+		"LoginRedirect": {
+			reason: "A redirect to the login page should be classified as an SSO redirect.",
+			res:    response(http.StatusFound, http.Header{"Location": {"https://example.service-now.com/login_with_sso.do?glide_sso_id=1"}}, ""),
+			want:   ReasonSSORedirect,
+		},
+		"SAMLRedirect": {
+			reason: "A redirect to a SAML identity provider should be classified as an SSO redirect.",
+			res:    response(http.StatusFound, http.Header{"Location": {"https://idp.example.com/sso?SAMLRequest=abc"}}, ""),
+			want:   ReasonSSORedirect,
+		},
+		"BenignRedirect": {
+			reason: "A redirect that does not lead to a login page should not be classified.",
+			res:    response(http.StatusFound, http.Header{"Location": {"https://example.service-now.com/api/now/table/cmdb_ci"}}, ""),
+		},
+		"Hibernating": {
+			reason: "The page of a hibernating instance should be recognised.",
+			res:    html(http.StatusOK, `<html><head><title>Instance Hibernating page</title></head></html>`),
+			want:   ReasonHibernating,
+		},
+		"Maintenance": {
+			reason: "An HTML page served as 503 should be classified as maintenance.",
+			res:    html(http.StatusServiceUnavailable, `<html><body>Back soon</body></html>`),
+			want:   ReasonMaintenance,
+		},
+		"LoginPage": {
+			reason: "The login form should be classified as an SSO redirect.",
+			res:    html(http.StatusOK, `<form action="login.do" method="post"><input name="user_password" type="password"/></form>`),
+			want:   ReasonSSORedirect,
+		},
+		"IncidentalWords": {
+			reason: "An error page that merely mentions login, SSO or maintenance should only be unexpected.",
+			res:    html(http.StatusInternalServerError, `<html><body>Error. Check the SSO login settings or the maintenance schedule.</body></html>`),
+			want:   ReasonUnexpectedResponse,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := classifyResponse(tc.res)
+			if err != nil {
+				t.Fatalf("\n%s\nclassifyResponse(...): %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nclassifyResponse(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// A roundTripper returns the supplied response.
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestAvailabilityTransport(t *testing.T) {
+	cases := map[string]struct {
+		reason    string
+		res       *http.Response
+		suspended bool
+	}{
+		"BenignRedirect": {
+			reason: "A redirect that does not lead to a login page should be returned as is.",
+			res:    response(http.StatusFound, http.Header{"Location": {"/api/now/table/cmdb_ci"}}, ""),
+		},
+		"UnexpectedPage": {
+			reason: "An unexpected HTML page should fail the request without holding back others.",
+			res:    html(http.StatusInternalServerError, `<html><body>Please login again</body></html>`),
+		},
+		"Hibernating": {
+			reason:    "A hibernating instance should hold back further requests.",
+			res:       html(http.StatusOK, `<p>Your instance is hibernating.</p>`),
+			suspended: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			l := &instanceLimiter{name: name, limiter: rate.NewLimiter(rate.Inf, 0)}
+			tr := &availabilityTransport{limiter: l, next: roundTripper(func(_ *http.Request) (*http.Response, error) { return tc.res, nil })}
+			req, _ := http.NewRequest(http.MethodGet, "https://example.service-now.com/api/now/table/cmdb_ci", nil)
+			_, _ = tr.RoundTrip(req)
+			if got := l.suspended() != nil; got != tc.suspended {
+				t.Errorf("\n%s\nRoundTrip(...): want suspended %t, got %t", tc.reason, tc.suspended, got)
+			}
+		})
+	}
+}
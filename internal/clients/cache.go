@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	cmdb "github.com/anka-software/cmdb-sdk/pkg/client"
+)
+
+const (
+	// maxIdleConnsPerHost is raised from the net/http default of 2, since
+	// every reconcile worker of every controller talks to the same host.
+	maxIdleConnsPerHost = 32
+	idleConnTimeout     = 90 * time.Second
+)
+
+// A cachedClient is the client of a ProviderConfig, together with the HTTP
+// transport that holds its connections.
+type cachedClient struct {
+	version string
+	client  *cmdb.MainClient
+	base    *http.Transport
+}
+
+// A clientPool shares one client, and hence one authenticated transport and
+// its keep-alive connections, between all managed resources that use the
+// same ProviderConfig.
+type clientPool struct {
+	mu               sync.Mutex
+	byProviderConfig map[string]cachedClient
+}
+
+var clientCache = &clientPool{byProviderConfig: map[string]cachedClient{}}
+
+// get returns the cached client of the supplied config, creating it if the
+// config is not cached or was produced from another version of its
+// ProviderConfig or credentials.
+func (p *clientPool) get(c Config) *cmdb.MainClient {
+	if c.ProviderConfigName == "" || c.Version == "" {
+		return newClient(c, newHTTPTransport())
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cached, ok := p.byProviderConfig[c.ProviderConfigName]
+	if ok && cached.version == c.Version {
+		return cached.client
+	}
+	if ok {
+		cached.base.CloseIdleConnections()
+	}
+
+	base := newHTTPTransport()
+	cached = cachedClient{version: c.Version, client: newClient(c, base), base: base}
+	p.byProviderConfig[c.ProviderConfigName] = cached
+	return cached.client
+}
+
+// forget drops the cached client of the supplied ProviderConfig.
+func (p *clientPool) forget(providerConfigName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cached, ok := p.byProviderConfig[providerConfigName]; ok {
+		cached.base.CloseIdleConnections()
+		delete(p.byProviderConfig, providerConfigName)
+	}
+}
+
+// ForgetProviderConfig drops the cached client of the supplied ProviderConfig
+// and closes its idle connections. It should be called once the
+// ProviderConfig is deleted.
+func ForgetProviderConfig(providerConfigName string) {
+	clientCache.forget(providerConfigName)
+}
+
+// newHTTPTransport returns an HTTP transport that keeps enough connections
+// alive to be shared by all managed resources of a ProviderConfig.
+func newHTTPTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	t.IdleConnTimeout = idleConnTimeout
+	return t
+}
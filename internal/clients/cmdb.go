@@ -18,6 +18,10 @@ package clients
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/go-openapi/runtime"
 
@@ -37,11 +41,55 @@ import (
 	"github.com/go-openapi/strfmt"
 )
 
+// defaultRequestTimeout is the timeout of the SDK, which it does not apply
+// to requests that are passed a context.
+const defaultRequestTimeout = 30 * time.Second
+
 // Config for ServiceNow authentication struct
 type Config struct {
 	BaseURL  string
 	Username string
 	Password string
+
+	// ProviderConfigName is the ProviderConfig the config was produced from.
+	// Clients of the same ProviderConfig share a rate limiter.
+	ProviderConfigName string
+
+	// Version identifies the revision of the ProviderConfig and credentials
+	// the config was produced from. Clients are shared between configs of
+	// the same ProviderConfig and Version.
+	Version string
+
+	// RequestTimeout bounds each call to a ServiceNow API, including its
+	// retries. Defaults to defaultRequestTimeout.
+	RequestTimeout time.Duration
+
+	// RequestsPerSecond that may be sent to the instance, or zero for no
+	// limit. Burst defaults to RequestsPerSecond.
+	RequestsPerSecond int
+	Burst             int
+
+	// LogRequests logs requests and responses even if the provider does not
+	// run with debug logging. RedactFields are redacted from them.
+	LogRequests  bool
+	RedactFields []string
+
+	// LabelFields and AnnotationFields map the label and annotation keys of
+	// managed resources to the CI fields their values are set to.
+	LabelFields      map[string]string
+	AnnotationFields map[string]string
+
+	// ReadOnly clients must not be used to change the instance. Controllers
+	// report the changes they would make instead.
+	ReadOnly bool
+
+	// ChangeControl gates updates to CIs on approved change requests, unless
+	// it is nil.
+	ChangeControl *v1alpha1.ChangeControl
+
+	// Schedules restrict the times at which changes are made to the
+	// instance, unless they are nil.
+	Schedules *v1alpha1.Schedules
 }
 
 /*
@@ -55,26 +103,56 @@ func NewClient(c Config) *cmdb.MainClient {
 */
 
 // NewClient creates new ServiceNow with provided Configurations and Credentials.
+// Clients are cached per ProviderConfig, see Config.Version.
 func NewClient(c Config) *cmdb.MainClient {
-	transport := GetTransportWithAuthentication(c)
-	apiClient := cmdb.New(transport, strfmt.Default)
+	return clientCache.get(c)
+}
 
-	return apiClient
+// newClient creates a new ServiceNow client that sends requests through the
+// supplied HTTP transport.
+func newClient(c Config, base *http.Transport) *cmdb.MainClient {
+	return cmdb.New(newTransportWithAuthentication(c, base), strfmt.Default)
 }
 
 // GetTransportWithAuthentication returns the REST config with authentication header
 func GetTransportWithAuthentication(c Config) runtime.ClientTransport {
-	transport := httptransport.New(c.BaseURL, "/api/now", nil)
-	transport.SetDebug(true)
+	return newTransportWithAuthentication(c, newHTTPTransport())
+}
+
+func newTransportWithAuthentication(c Config, base *http.Transport) runtime.ClientTransport {
+	limiter := getLimiter(c)
+	timeout := c.RequestTimeout
+	if timeout == 0 {
+		timeout = defaultRequestTimeout
+	}
+	host, schemes := splitBaseURL(c.BaseURL)
+	transport := httptransport.NewWithClient(host, "/api/now", schemes, &http.Client{
+		Transport:     &retryTransport{next: &rateLimitedTransport{limiter: limiter, next: &metricsTransport{providerConfig: c.ProviderConfigName, next: &tracingTransport{next: &availabilityTransport{limiter: limiter, next: newLoggingTransport(c, base)}}}}},
+		Timeout:       timeout,
+		CheckRedirect: noRedirect,
+	})
+	transport.EnableConnectionReuse()
+	// Requests are logged by the loggingTransport, which redacts secrets.
+	transport.SetDebug(false)
 	transport.DefaultAuthentication = httptransport.BasicAuth(c.Username, c.Password)
 
 	return transport
 }
 
+// splitBaseURL returns the host and scheme of the supplied base URL. Base
+// URLs are usually a host name, which is served over HTTPS.
+func splitBaseURL(baseURL string) (string, []string) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" || u.Scheme == "" {
+		return baseURL, nil
+	}
+	return u.Host, []string{u.Scheme}
+}
+
 // GetTransport returns the REST config
 func GetTransport(c Config) runtime.ClientTransport {
 	transport := httptransport.New(c.BaseURL, "", nil)
-	transport.SetDebug(true)
+	transport.SetDebug(false)
 
 	return transport
 }
@@ -101,6 +179,12 @@ func UseProviderConfig(ctx context.Context, c client.Client, mg resource.Managed
 		return nil, errors.Wrap(err, "cannot track ProviderConfig usage")
 	}
 
+	return ConfigFromProviderConfig(ctx, c, pc)
+}
+
+// ConfigFromProviderConfig produces a config from the supplied ProviderConfig
+// and the credentials it references.
+func ConfigFromProviderConfig(ctx context.Context, c client.Client, pc *v1alpha1.ProviderConfig) (*Config, error) {
 	switch s := pc.Spec.Credentials.Source; s { //nolint:exhaustive
 	case xpv1.CredentialsSourceSecret:
 		csr := pc.Spec.Credentials.SecretRef
@@ -111,7 +195,30 @@ func UseProviderConfig(ctx context.Context, c client.Client, mg resource.Managed
 		if err := c.Get(ctx, types.NamespacedName{Namespace: csr.Namespace, Name: csr.Name}, s); err != nil {
 			return nil, errors.Wrap(err, "cannot get credentials secret")
 		}
-		return &Config{BaseURL: pc.Spec.BaseURL, Username: pc.Spec.Username, Password: string(s.Data[csr.Key])}, nil
+		cfg := &Config{BaseURL: pc.Spec.BaseURL, Username: pc.Spec.Username, Password: string(s.Data[csr.Key]), ProviderConfigName: pc.GetName(), ReadOnly: pc.Spec.ReadOnly}
+		// The generation, unlike the resource version, does not change when
+		// the status of the ProviderConfig is updated.
+		cfg.Version = fmt.Sprintf("%s/%d/%s", pc.GetUID(), pc.GetGeneration(), s.GetResourceVersion())
+		if d := pc.Spec.Debug; d != nil {
+			cfg.LogRequests = d.LogRequests
+			cfg.RedactFields = d.RedactFields
+		}
+		cfg.ChangeControl = pc.Spec.ChangeControl
+		cfg.Schedules = pc.Spec.Schedules
+		if f := pc.Spec.FieldsFrom; f != nil {
+			cfg.LabelFields = f.Labels
+			cfg.AnnotationFields = f.Annotations
+		}
+		if t := pc.Spec.RequestTimeout; t != nil {
+			cfg.RequestTimeout = t.Duration
+		}
+		if rl := pc.Spec.RateLimit; rl != nil {
+			cfg.RequestsPerSecond = rl.RequestsPerSecond
+			if rl.Burst != nil {
+				cfg.Burst = *rl.Burst
+			}
+		}
+		return cfg, nil
 	default:
 		return nil, errors.Errorf("credentials source %s is not currently supported", s)
 	}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+	"github.com/pkg/errors"
+
+	"github.com/anka-software/cmdb-sdk/pkg/client/cmdb_meta"
+	"github.com/anka-software/cmdb-sdk/pkg/client/table"
+)
+
+const (
+	errTransient    = "ServiceNow is temporarily unavailable"
+	errUnauthorized = "ServiceNow rejected the credentials of the ProviderConfig"
+)
+
+// IsNotFound returns true if the supplied error indicates that the requested
+// ServiceNow record or class does not exist.
+func IsNotFound(err error) bool {
+	switch errors.Cause(err).(type) {
+	case *table.GetTableItemsNotFound, *table.DeleteRecordNotFound, *cmdb_meta.GetCmdbMetaNotFound:
+		return true
+	}
+	return statusCode(err) == http.StatusNotFound
+}
+
+// IsUnauthorized returns true if the supplied error indicates that ServiceNow
+// rejected the credentials or the permissions of the configured user.
+func IsUnauthorized(err error) bool {
+	switch errors.Cause(err).(type) {
+	case *table.GetTableItemsUnauthorized, *table.GetTableItemsForbidden,
+		*table.DeleteRecordUnauthorized, *table.DeleteRecordForbidden,
+		*cmdb_meta.GetCmdbMetaUnauthorized, *cmdb_meta.GetCmdbMetaForbidden:
+		return true
+	}
+	// The SDK reports both 403 and 500 Identification and Reconciliation
+	// responses as CreateIdentifyReconcileForbidden, so it is not classified.
+	code := statusCode(err)
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// IsTransient returns true if the supplied error is likely to go away if the
+// request is retried later, for example a 5xx response, a rate limited
+// response or a network failure.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rateLimited *RateLimitedError
+	if errors.As(err, &rateLimited) || isUnavailable(err) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	code := statusCode(err)
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// Annotate describes whether the supplied error is transient or caused by
+// the credentials, so that the two are distinguishable from errors that
+// require changes to the managed resource.
+func Annotate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case isUnavailable(err):
+		// Already describes what is wrong with the instance.
+		return err
+	case IsUnauthorized(err):
+		return errors.Wrap(err, errUnauthorized)
+	case IsTransient(err):
+		return errors.Wrap(err, errTransient)
+	}
+	return err
+}
+
+func isUnavailable(err error) bool {
+	_, ok := AsUnavailable(err)
+	return ok
+}
+
+// statusCode returns the HTTP status code of responses the SDK did not
+// expect, or zero.
+func statusCode(err error) int {
+	var apiErr *runtime.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
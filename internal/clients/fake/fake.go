@@ -0,0 +1,78 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides mock ServiceNow client services for unit tests.
+package fake
+
+import (
+	"github.com/go-openapi/runtime"
+
+	"github.com/anka-software/cmdb-sdk/pkg/client/cmdb"
+	"github.com/anka-software/cmdb-sdk/pkg/client/cmdb_meta"
+	"github.com/anka-software/cmdb-sdk/pkg/client/table"
+)
+
+var (
+	_ table.ClientService     = &MockTableClient{}
+	_ cmdb_meta.ClientService = &MockMetaClient{}
+	_ cmdb.ClientService      = &MockIdenReconClient{}
+)
+
+// MockTableClient is a mock Table API client service.
+type MockTableClient struct {
+	MockGetTableItems func(params *table.GetTableItemsParams) (*table.GetTableItemsOK, error)
+	MockDeleteRecord  func(params *table.DeleteRecordParams) (*table.DeleteRecordOK, error)
+}
+
+// GetTableItems calls MockGetTableItems.
+func (m *MockTableClient) GetTableItems(params *table.GetTableItemsParams, _ ...table.ClientOption) (*table.GetTableItemsOK, error) {
+	return m.MockGetTableItems(params)
+}
+
+// DeleteRecord calls MockDeleteRecord.
+func (m *MockTableClient) DeleteRecord(params *table.DeleteRecordParams, _ ...table.ClientOption) (*table.DeleteRecordOK, error) {
+	return m.MockDeleteRecord(params)
+}
+
+// SetTransport does nothing.
+func (m *MockTableClient) SetTransport(_ runtime.ClientTransport) {}
+
+// MockMetaClient is a mock CMDB Meta API client service.
+type MockMetaClient struct {
+	MockGetCmdbMetaByClassName func(params *cmdb_meta.GetCmdbMetaParams) (*cmdb_meta.GetCmdbMetaOK, error)
+}
+
+// GetCmdbMetaByClassName calls MockGetCmdbMetaByClassName.
+func (m *MockMetaClient) GetCmdbMetaByClassName(params *cmdb_meta.GetCmdbMetaParams, _ ...cmdb_meta.ClientOption) (*cmdb_meta.GetCmdbMetaOK, error) {
+	return m.MockGetCmdbMetaByClassName(params)
+}
+
+// SetTransport does nothing.
+func (m *MockMetaClient) SetTransport(_ runtime.ClientTransport) {}
+
+// MockIdenReconClient is a mock Identification and Reconciliation API client
+// service.
+type MockIdenReconClient struct {
+	MockCreateIdentifyReconcile func(params *cmdb.CreateIdentifyReconcileParams) (*cmdb.CreateIdentifyReconcileCreated, error)
+}
+
+// CreateIdentifyReconcile calls MockCreateIdentifyReconcile.
+func (m *MockIdenReconClient) CreateIdentifyReconcile(params *cmdb.CreateIdentifyReconcileParams, _ ...cmdb.ClientOption) (*cmdb.CreateIdentifyReconcileCreated, error) {
+	return m.MockCreateIdentifyReconcile(params)
+}
+
+// SetTransport does nothing.
+func (m *MockIdenReconClient) SetTransport(_ runtime.ClientTransport) {}
@@ -17,7 +17,9 @@ limitations under the License.
 package idenrecon
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/anka-software/cmdb-sdk/pkg/client/cmdb"
@@ -35,9 +37,9 @@ func NewIdenReconClient(cfg clients.Config) cmdb.ClientService {
 }
 
 // GenerateCIOptions creates/updates.
-func GenerateCIOptions(d *v1alpha1.CIParameters) *cmdb.CreateIdentifyReconcileParams {
+func GenerateCIOptions(ctx context.Context, d *v1alpha1.CIParameters) *cmdb.CreateIdentifyReconcileParams {
 	d.Values["name"] = d.Name
-	var params = cmdb.NewCreateIdentifyReconcileParams().WithSysParamDataSource(
+	var params = cmdb.NewCreateIdentifyReconcileParams().WithContext(ctx).WithSysParamDataSource(
 		&d.SysParamDataSource).WithBody(&models.IdentifyReconcileItemList{
 		Items: []*models.IdentifyReconcileItem{{ClassName: d.ClassName, Values: d.Values}},
 	})
@@ -47,16 +49,32 @@ func GenerateCIOptions(d *v1alpha1.CIParameters) *cmdb.CreateIdentifyReconcilePa
 
 // IsResourceUpToDate for observation
 func IsResourceUpToDate(desired map[string]string, current map[string]interface{}) bool {
+	return len(GetOutdatedFields(desired, current)) == 0
+}
+
+// GetOutdatedFields returns the sorted names of the desired fields whose
+// current value differs.
+func GetOutdatedFields(desired map[string]string, current map[string]interface{}) []string {
+	var outdated []string
 	for k, v := range desired {
 		str, ok := current[k].(string)
-		if ok {
-			if str != v {
-				fmt.Printf("The field %v is not up to date. Current State:%v Desired State:%v\n", k, str, v)
-				return false
-			}
+		if ok && str != v {
+			outdated = append(outdated, k)
 		}
 	}
-	return true
+	sort.Strings(outdated)
+	return outdated
+}
+
+// GetFieldNames returns the sorted names of the supplied fields, so that
+// they can be logged without their values.
+func GetFieldNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // ContainsField for linter
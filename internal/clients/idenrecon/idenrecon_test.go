@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idenrecon
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/anka-software/cmdb-sdk/pkg/models"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+)
+
+func TestGenerateCIOptions(t *testing.T) {
+	ctx := context.Background()
+	d := &v1alpha1.CIParameters{
+		SysParamDataSource: "ServiceNow",
+		ClassName:          "cmdb_ci_linux_server",
+		Name:               "web-1",
+		Values:             map[string]string{"ram": "2048"},
+	}
+
+	got := GenerateCIOptions(ctx, d)
+
+	want := &models.IdentifyReconcileItemList{Items: []*models.IdentifyReconcileItem{{
+		ClassName: "cmdb_ci_linux_server",
+		Values:    map[string]string{"name": "web-1", "ram": "2048"},
+	}}}
+	if diff := cmp.Diff(want, got.Body); diff != "" {
+		t.Errorf("GenerateCIOptions(...): -want body, +got body:\n%s\n", diff)
+	}
+	if got.SysparmDataSource == nil || *got.SysparmDataSource != "ServiceNow" {
+		t.Errorf("GenerateCIOptions(...): want data source ServiceNow, got %v", got.SysparmDataSource)
+	}
+	if got.Context != ctx {
+		t.Errorf("GenerateCIOptions(...): want the supplied context")
+	}
+}
+
+func TestIsResourceUpToDate(t *testing.T) {
+	cases := map[string]struct {
+		reason  string
+		desired map[string]string
+		current map[string]interface{}
+		want    bool
+	}{
+		"UpToDate": {
+			reason:  "A CI with every desired value should be up to date.",
+			desired: map[string]string{"name": "web-1", "ram": "2048"},
+			current: map[string]interface{}{"name": "web-1", "ram": "2048", "os": "Linux"},
+			want:    true,
+		},
+		"Drifted": {
+			reason:  "A CI with a different value should not be up to date.",
+			desired: map[string]string{"name": "web-1", "ram": "2048"},
+			current: map[string]interface{}{"name": "web-1", "ram": "1024"},
+			want:    false,
+		},
+		"ReferenceField": {
+			reason:  "Fields that are not returned as strings, like references, should be ignored.",
+			desired: map[string]string{"owned_by": "admin"},
+			current: map[string]interface{}{"owned_by": map[string]interface{}{"value": "6816f79cc0a8016401c5a33be04be441"}},
+			want:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := IsResourceUpToDate(tc.desired, tc.current)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nIsResourceUpToDate(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestContainsField(t *testing.T) {
+	fields := []string{"name", "ram", "ram_size", "os"}
+
+	cases := map[string]struct {
+		reason string
+		values map[string]string
+		want   error
+	}{
+		"Known": {
+			reason: "Values of known fields should be accepted.",
+			values: map[string]string{"name": "web-1", "ram": "2048"},
+		},
+		"Unknown": {
+			reason: "A value of an unknown field should be reported with similar fields.",
+			values: map[string]string{"ra": "2048"},
+			want:   fmt.Errorf("The field:ra is not recognized.\nAvailable fields that are similar to ra:\n[ram ram_size]"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ContainsField(fields, tc.values)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nContainsField(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGetSimilarFields(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		fields []string
+		field  string
+		want   []string
+	}{
+		"Similar": {
+			reason: "Fields containing the supplied field should be returned in order.",
+			fields: []string{"ip_address", "mac_address", "name"},
+			field:  "address",
+			want:   []string{"ip_address", "mac_address"},
+		},
+		"None": {
+			reason: "No fields should be returned if none are similar.",
+			fields: []string{"name", "ram"},
+			field:  "cpu",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := GetSimilarFields(tc.fields, tc.field)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nGetSimilarFields(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package idenrecon
+
+import (
+	"context"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+
+	"github.com/anka-software/cmdb-sdk/pkg/client/cmdb"
+	"github.com/anka-software/cmdb-sdk/pkg/models"
+)
+
+// RelationContains is the type of the relation between a CI and the CIs it
+// contains.
+const RelationContains = "Contains::Contained by"
+
+// A Relation between two items of an Identification and Reconciliation API
+// payload, identified by their index in the payload.
+type Relation struct {
+	Parent int    `json:"parent"`
+	Child  int    `json:"child"`
+	Type   string `json:"type"`
+}
+
+// GenerateItemsOptions creates or updates the supplied CIs, reported by the
+// supplied data source.
+func GenerateItemsOptions(ctx context.Context, source string, items []*models.IdentifyReconcileItem) *cmdb.CreateIdentifyReconcileParams {
+	return cmdb.NewCreateIdentifyReconcileParams().WithContext(ctx).WithSysParamDataSource(&source).
+		WithBody(&models.IdentifyReconcileItemList{Items: items})
+}
+
+// WithRelations sends the supplied relations between the items of a payload.
+// The SDK payload has no relations, so they are added when the request is
+// written.
+func WithRelations(relations []Relation) cmdb.ClientOption {
+	return func(op *runtime.ClientOperation) {
+		if len(relations) == 0 {
+			return
+		}
+		if p, ok := op.Params.(*cmdb.CreateIdentifyReconcileParams); ok {
+			op.Params = &relationsWriter{params: p, relations: relations}
+		}
+	}
+}
+
+// A relationsWriter writes an Identification and Reconciliation API request
+// whose payload has relations.
+type relationsWriter struct {
+	params    *cmdb.CreateIdentifyReconcileParams
+	relations []Relation
+}
+
+type itemsWithRelations struct {
+	Items     []*models.IdentifyReconcileItem `json:"items"`
+	Relations []Relation                      `json:"relations"`
+}
+
+func (w *relationsWriter) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+	if err := w.params.WriteToRequest(r, reg); err != nil {
+		return err
+	}
+	var items []*models.IdentifyReconcileItem
+	if w.params.Body != nil {
+		items = w.params.Body.Items
+	}
+	return r.SetBodyParam(&itemsWithRelations{Items: items, Relations: w.relations})
+}
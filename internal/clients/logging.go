@@ -0,0 +1,321 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+const (
+	redacted = "REDACTED"
+
+	// paramQuery is the encoded query of a Table API request.
+	paramQuery = "sysparm_query"
+)
+
+// sensitiveHeaders are never logged.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Usertoken":         true,
+}
+
+// sensitiveFields are redacted from every logged body, in addition to the
+// CI fields configured on the ProviderConfig.
+var sensitiveFields = []string{"password", "client_secret", "access_token", "refresh_token", "id_token", "token"}
+
+var wireLog = struct {
+	sync.RWMutex
+	log   logging.Logger
+	debug bool
+}{log: logging.NewNopLogger()}
+
+// SetWireLogger sets the logger HTTP requests to ServiceNow and their
+// responses are logged to. They are logged at debug level for every
+// ProviderConfig if debug is true, and otherwise only for ProviderConfigs
+// that enable request logging.
+func SetWireLogger(l logging.Logger, debug bool) {
+	wireLog.Lock()
+	defer wireLog.Unlock()
+	wireLog.log = l
+	wireLog.debug = debug
+}
+
+// newLoggingTransport returns a transport that logs requests and responses
+// with secrets redacted, or next if the supplied config is not logged.
+func newLoggingTransport(c Config, next http.RoundTripper) http.RoundTripper {
+	wireLog.RLock()
+	defer wireLog.RUnlock()
+
+	if !wireLog.debug && !c.LogRequests {
+		return next
+	}
+
+	log := wireLog.log.Debug
+	if c.LogRequests {
+		log = wireLog.log.Info
+	}
+
+	return &loggingTransport{log: log, providerConfig: c.ProviderConfigName, fields: redactedFields(c), next: next}
+}
+
+// A loggingTransport logs HTTP requests and responses.
+type loggingTransport struct {
+	log            func(msg string, keysAndValues ...interface{})
+	providerConfig string
+	fields         map[string]bool
+	next           http.RoundTripper
+}
+
+// RoundTrip logs the request, sends it, then logs the response.
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, body, err := peekRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	fields := t.redactedFields(req.Context())
+	t.log("Sending ServiceNow request",
+		"providerConfig", t.providerConfig,
+		"method", req.Method,
+		"url", redactURL(fields, req.URL),
+		"headers", redactHeaders(req.Header),
+		"body", redactBody(fields, req.Header.Get("Content-Type"), body))
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.log("ServiceNow request failed", "providerConfig", t.providerConfig, "method", req.Method, "url", redactURL(fields, req.URL), "error", err)
+		return res, err
+	}
+
+	body, err = peekResponseBody(res)
+	if err != nil {
+		return nil, err
+	}
+	t.log("Received ServiceNow response",
+		"providerConfig", t.providerConfig,
+		"method", req.Method,
+		"url", redactURL(fields, req.URL),
+		"status", res.StatusCode,
+		"headers", redactHeaders(res.Header),
+		"body", redactBody(fields, res.Header.Get("Content-Type"), body))
+
+	return res, nil
+}
+
+// peekRequestBody returns the body of the request, and a copy of the
+// request whose body is still readable.
+func peekRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	_ = req.Body.Close()
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return req, b, nil
+}
+
+// peekResponseBody returns the body of the response, leaving it readable.
+func peekResponseBody(res *http.Response) ([]byte, error) {
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// redactHeaders returns the supplied headers as a sorted list of strings,
+// with credentials redacted.
+func redactHeaders(h http.Header) []string {
+	out := make([]string, 0, len(h))
+	for k, v := range h {
+		value := strings.Join(v, ",")
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] || strings.Contains(strings.ToLower(k), "token") {
+			value = redacted
+		}
+		out = append(out, k+": "+value)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// redactURL returns the supplied URL with the query parameters named after
+// the supplied fields redacted, and its encoded query redacted if it
+// compares any of them.
+func redactURL(fields map[string]bool, u *url.URL) string {
+	q := u.Query()
+	for k, v := range q {
+		if fields[strings.ToLower(k)] || (k == paramQuery && queriesFields(fields, v)) {
+			q.Set(k, redacted)
+		}
+	}
+	r := *u
+	r.User = nil
+	r.RawQuery = q.Encode()
+	return r.String()
+}
+
+// queriesFields returns true if any of the supplied encoded queries has a
+// term that compares one of the supplied fields, e.g. serial_number=S3CR3T.
+func queriesFields(fields map[string]bool, queries []string) bool {
+	for _, q := range queries {
+		for _, term := range strings.Split(q, "^") {
+			term = strings.TrimPrefix(strings.TrimPrefix(term, "NQ"), "OR")
+			// Field names are lower case, and operators upper case.
+			end := strings.IndexFunc(term, func(r rune) bool {
+				return !(r == '_' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z')
+			})
+			if end < 0 {
+				end = len(term)
+			}
+			if fields[term[:end]] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactedFields returns the fields redacted from the requests sent with the
+// supplied context.
+func (t *loggingTransport) redactedFields(ctx context.Context) map[string]bool {
+	extra := redactedFieldsFrom(ctx)
+	if len(extra) == 0 {
+		return t.fields
+	}
+	fields := make(map[string]bool, len(t.fields)+len(extra))
+	for f := range t.fields {
+		fields[f] = true
+	}
+	for _, f := range extra {
+		fields[strings.ToLower(f)] = true
+	}
+	return fields
+}
+
+// redactBody returns the supplied body with the values of the supplied fields
+// redacted. Bodies that are neither JSON nor form encoded are logged as is.
+func redactBody(fields map[string]bool, contentType string, b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		q, err := url.ParseQuery(string(b))
+		if err != nil {
+			return redacted
+		}
+		for k := range q {
+			if fields[strings.ToLower(k)] {
+				q.Set(k, redacted)
+			}
+		}
+		return q.Encode()
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	out, err := json.Marshal(redactValue(fields, v))
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+// redactedFields returns the lower case names of the fields redacted for the
+// supplied config, and the supplied extra fields.
+func redactedFields(c Config, extra ...string) map[string]bool {
+	fields := map[string]bool{}
+	for _, f := range append(append(sensitiveFields, c.RedactFields...), extra...) {
+		fields[strings.ToLower(f)] = true
+	}
+	return fields
+}
+
+type redactedFieldsKey struct{}
+
+// WithRedactedFields returns a context whose requests are logged with the
+// values of the supplied fields redacted, in addition to the fields redacted
+// for every request.
+func WithRedactedFields(ctx context.Context, fields ...string) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, redactedFieldsKey{}, append(append([]string{}, redactedFieldsFrom(ctx)...), fields...))
+}
+
+func redactedFieldsFrom(ctx context.Context) []string {
+	fields, _ := ctx.Value(redactedFieldsKey{}).([]string)
+	return fields
+}
+
+// redactValue recursively redacts the values of the supplied fields of the
+// supplied decoded JSON value.
+func redactValue(fields map[string]bool, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if fields[strings.ToLower(k)] {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactValue(fields, val)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(fields, v[i])
+		}
+		return v
+	}
+	return v
+}
+
+// RedactedJSON returns the supplied request body encoded as JSON, with the
+// values of sensitive fields and of the fields redacted by the supplied
+// config and context redacted.
+func RedactedJSON(ctx context.Context, c Config, body interface{}) (string, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "", err
+	}
+	out, err := json.Marshal(redactValue(redactedFields(c, redactedFieldsFrom(ctx)...), v))
+	return string(out), err
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRedactURL(t *testing.T) {
+	fields := redactedFields(Config{}, "serial_number")
+
+	cases := map[string]struct {
+		reason string
+		url    string
+		want   string
+	}{
+		"Parameter": {
+			reason: "Query parameters named after sensitive fields should be redacted.",
+			url:    "https://example.service-now.com/oauth_token.do?client_secret=s3cr3t",
+			want:   "https://example.service-now.com/oauth_token.do?client_secret=REDACTED",
+		},
+		"Query": {
+			reason: "Encoded queries that compare a sensitive field should be redacted.",
+			url:    "https://example.service-now.com/api/now/table/cmdb_ci?sysparm_query=name%3Dweb-1%5Eserial_number%3DS3CR3T",
+			want:   "https://example.service-now.com/api/now/table/cmdb_ci?sysparm_query=REDACTED",
+		},
+		"OrQuery": {
+			reason: "Alternatives of encoded queries that compare a sensitive field should be redacted.",
+			url:    "https://example.service-now.com/api/now/table/cmdb_ci?sysparm_query=name%3Dweb-1%5EORserial_numberSTARTSWITHS3",
+			want:   "https://example.service-now.com/api/now/table/cmdb_ci?sysparm_query=REDACTED",
+		},
+		"OtherQuery": {
+			reason: "Encoded queries that compare no sensitive field should be logged.",
+			url:    "https://example.service-now.com/api/now/table/cmdb_ci?sysparm_query=name%3Dserial_number",
+			want:   "https://example.service-now.com/api/now/table/cmdb_ci?sysparm_query=name%3Dserial_number",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatalf("url.Parse(...): %v", err)
+			}
+			if got := redactURL(fields, u); got != tc.want {
+				t.Errorf("\n%s\nredactURL(...): want %q, got %q", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
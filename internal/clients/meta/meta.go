@@ -17,6 +17,8 @@ limitations under the License.
 package meta
 
 import (
+	"context"
+
 	"github.com/anka-software/cmdb-sdk/pkg/client/cmdb_meta"
 
 	"github.com/crossplane/provider-cmdb/internal/clients"
@@ -30,9 +32,9 @@ func NewMetaClient(cfg clients.Config) cmdb_meta.ClientService {
 }
 
 // GenerateGetMetaOptions get items.
-func GenerateGetMetaOptions(className string) *cmdb_meta.GetCmdbMetaParams {
+func GenerateGetMetaOptions(ctx context.Context, className string) *cmdb_meta.GetCmdbMetaParams {
 
-	var params = cmdb_meta.NewGetCmdbMetaParams().WithClassName(
+	var params = cmdb_meta.NewGetCmdbMetaParams().WithContext(ctx).WithClassName(
 		className)
 
 	return params
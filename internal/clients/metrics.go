@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "cmdb"
+
+	labelProviderConfig = "provider_config"
+	labelAPI            = "api"
+	labelMethod         = "method"
+	labelCode           = "code"
+)
+
+// ServiceNow APIs, as reported by the api label.
+const (
+	apiTable             = "table"
+	apiIdentifyReconcile = "identifyreconcile"
+	apiCMDBMeta          = "cmdb_meta"
+	apiOther             = "other"
+)
+
+var (
+	rateLimiterWaiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "rate_limiter",
+		Name:      "waiting_requests",
+		Help:      "Number of ServiceNow requests waiting for the rate limiter of a ProviderConfig.",
+	}, []string{labelProviderConfig})
+
+	rateLimiterThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "rate_limiter",
+		Name:      "throttled_responses_total",
+		Help:      "Number of 429 Too Many Requests responses returned by the ServiceNow instance of a ProviderConfig.",
+	}, []string{labelProviderConfig})
+
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "servicenow",
+		Name:      "requests_total",
+		Help:      "Number of requests sent to ServiceNow, by API and HTTP status code.",
+	}, []string{labelProviderConfig, labelAPI, labelMethod, labelCode})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "servicenow",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of requests sent to ServiceNow, by API.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{labelProviderConfig, labelAPI, labelMethod})
+)
+
+func init() {
+	metrics.Registry.MustRegister(rateLimiterWaiting, rateLimiterThrottled, requestsTotal, requestDuration)
+}
+
+// A metricsTransport records the outcome and latency of every request sent
+// to ServiceNow.
+type metricsTransport struct {
+	providerConfig string
+	next           http.RoundTripper
+}
+
+// RoundTrip sends the request and records its metrics.
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	api := apiOf(req.URL.Path)
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	requestDuration.WithLabelValues(t.providerConfig, api, req.Method).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(res.StatusCode)
+	}
+	requestsTotal.WithLabelValues(t.providerConfig, api, req.Method, code).Inc()
+
+	return res, err
+}
+
+// apiOf returns the ServiceNow API the supplied request path belongs to.
+func apiOf(path string) string {
+	p := strings.TrimPrefix(path, "/api/now")
+	switch {
+	case strings.HasPrefix(p, "/table/"):
+		return apiTable
+	case strings.HasPrefix(p, "/identifyreconcile"):
+		return apiIdentifyReconcile
+	case strings.HasPrefix(p, "/cmdb/meta/"):
+		return apiCMDBMeta
+	}
+	return apiOther
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxRateLimitWait is the longest a request waits for a rate limited
+	// instance before giving up, so that it does not hold a reconcile worker.
+	maxRateLimitWait = 30 * time.Second
+
+	// defaultRetryAfter is used when a rate limited response does not say
+	// when to retry.
+	defaultRetryAfter = 5 * time.Second
+
+	maxRateLimitRetries = 3
+)
+
+// A RateLimitedError is returned when a ServiceNow instance rejects requests
+// for longer than a reconcile is willing to wait.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("ServiceNow instance is rate limited, retry after %s", e.RetryAfter)
+}
+
+// An instanceLimiter is a token bucket shared by every request sent to the
+// ServiceNow instance of a ProviderConfig.
+type instanceLimiter struct {
+	name    string
+	limiter *rate.Limiter
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+	unavailable *UnavailableError
+}
+
+// setLimit updates the token bucket to the supplied requests per second and
+// burst. Zero requests per second removes the limit.
+func (l *instanceLimiter) setLimit(rps, burst int) {
+	limit := rate.Inf
+	if rps > 0 {
+		limit = rate.Limit(rps)
+	}
+	if burst <= 0 {
+		burst = rps
+	}
+	if l.limiter.Limit() != limit {
+		l.limiter.SetLimit(limit)
+	}
+	if l.limiter.Burst() != burst {
+		l.limiter.SetBurst(burst)
+	}
+}
+
+// pause stops requests from being sent for the supplied duration.
+func (l *instanceLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// suspend stops requests from being sent until the supplied unavailable
+// instance is expected to be reachable again. Requests fail with the
+// supplied error meanwhile.
+func (l *instanceLimiter) suspend(e *UnavailableError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(e.RetryAfter); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.unavailable = e
+}
+
+// suspended returns why the instance is unavailable, if requests are
+// suspended.
+func (l *instanceLimiter) suspended() *UnavailableError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.unavailable == nil {
+		return nil
+	}
+	d := time.Until(l.pausedUntil)
+	if d <= 0 {
+		l.unavailable = nil
+		return nil
+	}
+	return &UnavailableError{Reason: l.unavailable.Reason, StatusCode: l.unavailable.StatusCode, RetryAfter: d}
+}
+
+// retryAfter returns how long requests are paused for.
+func (l *instanceLimiter) retryAfter() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Until(l.pausedUntil)
+}
+
+// wait blocks until a request may be sent, or returns a RateLimitedError if
+// that would take too long.
+func (l *instanceLimiter) wait(ctx context.Context) error {
+	rateLimiterWaiting.WithLabelValues(l.name).Inc()
+	defer rateLimiterWaiting.WithLabelValues(l.name).Dec()
+
+	if e := l.suspended(); e != nil {
+		return e
+	}
+	if d := l.retryAfter(); d > 0 {
+		if d > maxRateLimitWait {
+			return &RateLimitedError{RetryAfter: d}
+		}
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return l.limiter.Wait(ctx)
+}
+
+var limiters = struct {
+	sync.Mutex
+	byProviderConfig map[string]*instanceLimiter
+}{byProviderConfig: map[string]*instanceLimiter{}}
+
+// getLimiter returns the limiter of the supplied config. Configs produced
+// from the same ProviderConfig share a limiter.
+func getLimiter(c Config) *instanceLimiter {
+	limiters.Lock()
+	defer limiters.Unlock()
+
+	l, ok := limiters.byProviderConfig[c.ProviderConfigName]
+	if !ok {
+		l = &instanceLimiter{name: c.ProviderConfigName, limiter: rate.NewLimiter(rate.Inf, 0)}
+		if c.ProviderConfigName != "" {
+			limiters.byProviderConfig[c.ProviderConfigName] = l
+		}
+	}
+	l.setLimit(c.RequestsPerSecond, c.Burst)
+	return l
+}
+
+// RetryAfter returns how long the instance of the supplied ProviderConfig
+// has asked to not receive requests for.
+func RetryAfter(providerConfigName string) time.Duration {
+	limiters.Lock()
+	l, ok := limiters.byProviderConfig[providerConfigName]
+	limiters.Unlock()
+	if !ok {
+		return 0
+	}
+	return l.retryAfter()
+}
+
+// A rateLimitedTransport sends requests through an instanceLimiter and
+// retries requests rejected with 429 Too Many Requests once the instance
+// allows it.
+type rateLimitedTransport struct {
+	limiter *instanceLimiter
+	next    http.RoundTripper
+}
+
+// RoundTrip sends the request once the rate limiter allows it.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		res, err := t.next.RoundTrip(req)
+		if err != nil || res.StatusCode != http.StatusTooManyRequests {
+			return res, err
+		}
+
+		d := parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+		t.limiter.pause(d)
+		rateLimiterThrottled.WithLabelValues(t.limiter.name).Inc()
+
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+
+		// Requests whose body cannot be replayed are not retried.
+		if attempt >= maxRateLimitRetries || (req.Body != nil && req.GetBody == nil) {
+			return nil, &RateLimitedError{RetryAfter: d}
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return defaultRetryAfter
+}
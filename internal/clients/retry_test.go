@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestShouldRetry(t *testing.T) {
+	urlError := func(err error) error {
+		return &url.Error{Op: http.MethodGet, URL: "https://example.service-now.com/api/now/table/cmdb_ci", Err: err}
+	}
+
+	cases := map[string]struct {
+		reason string
+		res    *http.Response
+		err    error
+		want   bool
+	}{
+		"Timeout": {
+			reason: "A request that timed out should be retried.",
+			err:    urlError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ETIMEDOUT)}),
+			want:   true,
+		},
+		"ConnectionReset": {
+			reason: "A request whose connection was reset should be retried.",
+			err:    urlError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}),
+			want:   true,
+		},
+		"ConnectionRefused": {
+			reason: "A request whose connection was refused should be retried.",
+			err:    urlError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}),
+			want:   true,
+		},
+		"UnexpectedEOF": {
+			reason: "A response that ended early should be retried.",
+			err:    urlError(io.ErrUnexpectedEOF),
+			want:   true,
+		},
+		"UnknownHost": {
+			reason: "A host that does not exist should not be retried.",
+			err:    urlError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.service-now.com", IsNotFound: true}}),
+		},
+		"UnknownAuthority": {
+			reason: "A certificate that cannot be verified should not be retried.",
+			err:    urlError(x509.UnknownAuthorityError{}),
+		},
+		"Canceled": {
+			reason: "A canceled request should not be retried.",
+			err:    urlError(context.Canceled),
+		},
+		"DeadlineExceeded": {
+			reason: "A request whose deadline passed should not be retried.",
+			err:    urlError(context.DeadlineExceeded),
+		},
+		"Unavailable": {
+			reason: "An unavailable instance should not be retried.",
+			err:    &UnavailableError{},
+		},
+		"Other": {
+			reason: "Other errors should not be retried.",
+			err:    errors.New("boom"),
+		},
+		"BadGateway": {
+			reason: "A response of a proxy whose upstream failed should be retried.",
+			res:    &http.Response{StatusCode: http.StatusBadGateway},
+			want:   true,
+		},
+		"NotFound": {
+			reason: "Other responses should not be retried.",
+			res:    &http.Response{StatusCode: http.StatusNotFound},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := shouldRetry(tc.res, tc.err); got != tc.want {
+				t.Errorf("\n%s\nshouldRetry(...): want %t, got %t", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	errCronFields = "cron expression must have five fields: minute, hour, day of month, month and day of week"
+	errCronValue  = "invalid cron value"
+)
+
+// A Cron expression with the five standard fields. Fields may be *, numbers,
+// ranges such as 1-5, lists such as 1,3,5, and steps such as */15 or 8-18/2.
+// Days of the week are 0 to 7, where both 0 and 7 are Sunday.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar are true if the day of month or day of week field
+	// is *. If neither is, a day matches if either field does.
+	domStar, dowStar bool
+}
+
+// ParseCron parses the supplied cron expression.
+func ParseCron(expr string) (*Cron, error) {
+	f := strings.Fields(expr)
+	if len(f) != 5 {
+		return nil, errors.New(errCronFields)
+	}
+	c := &Cron{domStar: f[2] == "*", dowStar: f[4] == "*"}
+	var err error
+	for _, p := range []struct {
+		field    string
+		min, max int
+		bits     *uint64
+	}{
+		{f[0], 0, 59, &c.minute},
+		{f[1], 0, 23, &c.hour},
+		{f[2], 1, 31, &c.dom},
+		{f[3], 1, 12, &c.month},
+		{f[4], 0, 7, &c.dow},
+	} {
+		if *p.bits, err = parseField(p.field, p.min, p.max); err != nil {
+			return nil, err
+		}
+	}
+	// Sunday is both 0 and 7.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s < 1 {
+				return 0, errors.Errorf("%s %q", errCronValue, part)
+			}
+			rng, step = part[:i], s
+		}
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			i := strings.Index(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(rng[:i]); err != nil {
+				return 0, errors.Errorf("%s %q", errCronValue, part)
+			}
+			if hi, err = strconv.Atoi(rng[i+1:]); err != nil {
+				return 0, errors.Errorf("%s %q", errCronValue, part)
+			}
+		default:
+			v, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, errors.Errorf("%s %q", errCronValue, part)
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, errors.Errorf("%s %q", errCronValue, part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// searchYears bounds the search for the next activation of an expression that
+// may never match, such as one for February 30.
+const searchYears = 5
+
+// Matches returns true if the expression matches the minute of the supplied
+// time, in its location.
+func (c *Cron) Matches(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	return c.matchesDay(t)
+}
+
+// Next returns the earliest minute from the supplied time, inclusive, that the
+// expression matches, in the location of the time. Months, days and hours
+// that do not match are skipped as a whole. It returns the zero time if the
+// expression matches no minute within five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	if m := t.Truncate(time.Minute); m.Before(t) {
+		t = m.Add(time.Minute)
+	}
+	limit := t.AddDate(searchYears, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			// Hours are skipped in elapsed time, so that an hour repeated
+			// when daylight saving time ends is not skipped back into.
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay returns true if the expression matches the day of the supplied
+// time.
+func (c *Cron) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
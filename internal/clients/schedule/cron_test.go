@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestParseCron(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		expr   string
+		err    error
+	}{
+		"Valid": {
+			reason: "Lists, ranges and steps should be parsed.",
+			expr:   "*/15 8-18/2 1,15 * 1-5",
+		},
+		"TooFewFields": {
+			reason: "Expressions without five fields should be rejected.",
+			expr:   "0 22 * *",
+			err:    errors.New(errCronFields),
+		},
+		"OutOfRange": {
+			reason: "Values outside the range of a field should be rejected.",
+			expr:   "0 24 * * *",
+			err:    errors.Errorf("%s %q", errCronValue, "24"),
+		},
+		"InvalidStep": {
+			reason: "Steps that are not positive numbers should be rejected.",
+			expr:   "*/0 * * * *",
+			err:    errors.Errorf("%s %q", errCronValue, "*/0"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseCron(tc.expr)
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nParseCron(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	// A Monday.
+	monday := time.Date(2022, time.October, 3, 22, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		reason string
+		expr   string
+		t      time.Time
+		want   bool
+	}{
+		"Weekday": {
+			reason: "A weekday should match a range of days of the week.",
+			expr:   "0 22 * * 1-5",
+			t:      monday,
+			want:   true,
+		},
+		"Weekend": {
+			reason: "A weekend day should not match a range of weekdays.",
+			expr:   "0 22 * * 1-5",
+			t:      monday.AddDate(0, 0, 5),
+			want:   false,
+		},
+		"Sunday": {
+			reason: "Sunday should be both 0 and 7.",
+			expr:   "0 22 * * 7",
+			t:      monday.AddDate(0, 0, 6),
+			want:   true,
+		},
+		"Minute": {
+			reason: "Other minutes of the hour should not match.",
+			expr:   "0 22 * * *",
+			t:      monday.Add(time.Minute),
+			want:   false,
+		},
+		"DayOfMonthOrWeek": {
+			reason: "A day should match if either its day of month or day of week does.",
+			expr:   "0 22 15 * 0",
+			t:      monday.AddDate(0, 0, 12),
+			want:   true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(...): %v", err)
+			}
+			if got := c.Matches(tc.t); got != tc.want {
+				t.Errorf("\n%s\nMatches(...): want %t, got %t", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// A Monday.
+	monday := time.Date(2022, time.October, 3, 22, 0, 0, 0, time.UTC)
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	if err != nil {
+		t.Fatalf("time.LoadLocation(...): %v", err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("time.LoadLocation(...): %v", err)
+	}
+
+	cases := map[string]struct {
+		reason string
+		expr   string
+		t      time.Time
+		want   time.Time
+	}{
+		"Now": {
+			reason: "A matching minute should be its own next activation.",
+			expr:   "0 22 * * 1-5",
+			t:      monday,
+			want:   monday,
+		},
+		"Seconds": {
+			reason: "A time within a matching minute should activate at the next matching minute.",
+			expr:   "*/15 * * * *",
+			t:      monday.Add(time.Second),
+			want:   monday.Add(15 * time.Minute),
+		},
+		"NextWeek": {
+			reason: "Days that do not match should be skipped.",
+			expr:   "30 2 * * 0",
+			t:      monday,
+			want:   time.Date(2022, time.October, 9, 2, 30, 0, 0, time.UTC),
+		},
+		"NextYear": {
+			reason: "Months that do not match should be skipped into the next year.",
+			expr:   "0 0 1 3 *",
+			t:      monday,
+			want:   time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"TimeZone": {
+			reason: "The expression should match the wall clock time of the location of the time.",
+			expr:   "0 1 * * *",
+			t:      monday.In(istanbul),
+			want:   time.Date(2022, time.October, 4, 1, 0, 0, 0, istanbul),
+		},
+		"DaylightSavingTimeEnds": {
+			reason: "An hour repeated when daylight saving time ends should not be searched again.",
+			expr:   "30 3 * * *",
+			t:      time.Date(2022, time.October, 30, 1, 45, 0, 0, time.UTC).In(berlin),
+			want:   time.Date(2022, time.October, 30, 3, 30, 0, 0, berlin),
+		},
+		"Never": {
+			reason: "An expression that never matches should return the zero time.",
+			expr:   "0 0 30 2 *",
+			t:      monday,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(...): %v", err)
+			}
+			if got := c.Next(tc.t); !got.Equal(tc.want) {
+				t.Errorf("\n%s\nNext(...): want %v, got %v", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sdkTable "github.com/anka-software/cmdb-sdk/pkg/client/table"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+)
+
+// A Gate defers changes to ServiceNow outside the write windows of a
+// ProviderConfig. A nil Gate is always open.
+type Gate struct {
+	cfg   clients.Config
+	table sdkTable.ClientService
+	now   func() time.Time
+}
+
+// NewGate returns a gate for the supplied config, which reads the schedule it
+// references with the supplied Table API client.
+func NewGate(cfg clients.Config, t sdkTable.ClientService) *Gate {
+	return &Gate{cfg: cfg, table: t, now: time.Now}
+}
+
+// Open returns true if changes may be made now. If the ProviderConfig has
+// schedules, the WriteWindow condition of the supplied resource is set, and
+// resources using the ProviderConfig are requeued when changes may next be
+// made.
+func (g *Gate) Open(ctx context.Context, mg resource.Conditioned) (bool, error) {
+	if g == nil || g.cfg.Schedules == nil {
+		return true, nil
+	}
+	now := g.now()
+	s, err := schedules.get(ctx, g.cfg, g.table, now)
+	if err != nil {
+		return false, err
+	}
+	next, ok := s.Next(now)
+	if ok && !next.After(now) {
+		mg.SetConditions(v1alpha1.WindowOpen())
+		return true, nil
+	}
+	if !ok {
+		// Look again once the horizon has passed.
+		next = now.Add(horizon)
+	}
+	windows.set(g.cfg.ProviderConfigName, next)
+	if !ok {
+		next = time.Time{}
+	}
+	mg.SetConditions(v1alpha1.WindowClosed(next))
+	return false, nil
+}
+
+// scheduleTTL is how long a loaded schedule is used before a referenced
+// cmn_schedule is read again, so that changes to it in ServiceNow are picked
+// up.
+const scheduleTTL = 15 * time.Minute
+
+// A cachedSchedule is the schedule of a ProviderConfig, together with the
+// version of the ProviderConfig it was loaded from.
+type cachedSchedule struct {
+	version  string
+	schedule *Schedule
+	loaded   time.Time
+}
+
+// A schedulePool shares the schedule of a ProviderConfig between all managed
+// resources that use it, so that a referenced cmn_schedule is not read on
+// every poll.
+type schedulePool struct {
+	mu               sync.Mutex
+	byProviderConfig map[string]cachedSchedule
+}
+
+var schedules = &schedulePool{byProviderConfig: map[string]cachedSchedule{}}
+
+// get returns the cached schedule of the supplied config, loading it if the
+// config is not cached, was produced from another version of its
+// ProviderConfig, or was loaded too long ago.
+func (p *schedulePool) get(ctx context.Context, cfg clients.Config, t sdkTable.ClientService, now time.Time) (*Schedule, error) {
+	if cfg.ProviderConfigName == "" || cfg.Version == "" {
+		return Load(ctx, cfg, t)
+	}
+
+	p.mu.Lock()
+	cached, ok := p.byProviderConfig[cfg.ProviderConfigName]
+	p.mu.Unlock()
+	if ok && cached.version == cfg.Version && now.Sub(cached.loaded) < scheduleTTL {
+		return cached.schedule, nil
+	}
+
+	// The schedule is loaded without holding the lock, so that a slow
+	// ServiceNow instance does not hold back other ProviderConfigs.
+	s, err := Load(ctx, cfg, t)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.byProviderConfig[cfg.ProviderConfigName] = cachedSchedule{version: cfg.Version, schedule: s, loaded: now}
+	return s, nil
+}
+
+// ForgetProviderConfig drops the cached schedule of the supplied
+// ProviderConfig, and when its write window opens. It should be called once
+// the ProviderConfig is deleted.
+func ForgetProviderConfig(providerConfigName string) {
+	schedules.mu.Lock()
+	delete(schedules.byProviderConfig, providerConfigName)
+	schedules.mu.Unlock()
+
+	windows.mu.Lock()
+	delete(windows.opens, providerConfigName)
+	windows.mu.Unlock()
+}
+
+// windows records when the write windows of ProviderConfigs whose changes
+// were deferred next open.
+var windows = &deferrals{opens: map[string]time.Time{}}
+
+type deferrals struct {
+	mu    sync.Mutex
+	opens map[string]time.Time
+}
+
+func (d *deferrals) set(providerConfigName string, opens time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.opens[providerConfigName] = opens
+}
+
+// OpensIn returns how long until the write window of the supplied
+// ProviderConfig opens, if changes of resources using it were deferred.
+func OpensIn(providerConfigName string) time.Duration {
+	windows.mu.Lock()
+	defer windows.mu.Unlock()
+	opens, ok := windows.opens[providerConfigName]
+	if !ok {
+		return 0
+	}
+	d := time.Until(opens)
+	if d <= 0 {
+		delete(windows.opens, providerConfigName)
+		return 0
+	}
+	return d
+}
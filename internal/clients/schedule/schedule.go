@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schedule decides when changes may be made to ServiceNow, according
+// to the write windows of a ProviderConfig.
+package schedule
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/provider-cmdb/apis/v1alpha1"
+)
+
+const (
+	errParseStart = "cannot parse start of window"
+	errTimeZone   = "cannot load time zone of window"
+)
+
+// horizon is how far ahead the next write window is looked for. It is long
+// enough for windows that open weekly.
+const horizon = 8 * 24 * time.Hour
+
+// An interval of time, which includes its start but not its end.
+type interval struct {
+	start, end time.Time
+}
+
+func (i interval) contains(t time.Time) bool {
+	return !t.Before(i.start) && t.Before(i.end)
+}
+
+// A source of the intervals in which a window is open.
+type source interface {
+	// intervals returns the intervals that overlap [from, to).
+	intervals(from, to time.Time) []interval
+}
+
+// A window opens on a cron schedule, for a duration.
+type window struct {
+	start    *Cron
+	duration time.Duration
+	loc      *time.Location
+}
+
+// newWindow returns the window of the supplied Window.
+func newWindow(w v1alpha1.Window) (*window, error) {
+	c, err := ParseCron(w.Start)
+	if err != nil {
+		return nil, errors.Wrap(err, errParseStart)
+	}
+	loc := time.UTC
+	if w.TimeZone != "" {
+		if loc, err = time.LoadLocation(w.TimeZone); err != nil {
+			return nil, errors.Wrap(err, errTimeZone)
+		}
+	}
+	return &window{start: c, duration: w.Duration.Duration, loc: loc}, nil
+}
+
+func (w *window) intervals(from, to time.Time) []interval {
+	var ivs []interval
+	for t := w.start.Next(from.Add(-w.duration).In(w.loc)); !t.IsZero() && t.Before(to); t = w.start.Next(t.Add(time.Minute)) {
+		start := t.In(from.Location())
+		ivs = append(ivs, interval{start: start, end: start.Add(w.duration)})
+	}
+	return ivs
+}
+
+// A Schedule of the times at which changes may be made.
+type Schedule struct {
+	allow, deny []source
+
+	// restricted schedules only allow changes in their allow windows.
+	restricted bool
+}
+
+// New returns the schedule of the supplied Schedules, without the windows of
+// a referenced cmn_schedule.
+func New(s *v1alpha1.Schedules) (*Schedule, error) {
+	sch := &Schedule{}
+	if s == nil {
+		return sch, nil
+	}
+	sch.restricted = len(s.Allow) > 0 || s.ScheduleSysID != nil
+	for _, w := range s.Allow {
+		win, err := newWindow(w)
+		if err != nil {
+			return nil, err
+		}
+		sch.allow = append(sch.allow, win)
+	}
+	for _, w := range s.Deny {
+		win, err := newWindow(w)
+		if err != nil {
+			return nil, err
+		}
+		sch.deny = append(sch.deny, win)
+	}
+	return sch, nil
+}
+
+// Next returns the earliest time from the supplied time at which changes may
+// be made. It returns false if changes may not be made within a week.
+func (s *Schedule) Next(now time.Time) (time.Time, bool) {
+	to := now.Add(horizon)
+
+	var allow, deny []interval
+	for _, src := range s.allow {
+		allow = append(allow, src.intervals(now, to)...)
+	}
+	for _, src := range s.deny {
+		deny = append(deny, src.intervals(now, to)...)
+	}
+	if !s.restricted {
+		allow = []interval{{start: now, end: to}}
+	}
+	allow, deny = merge(allow), merge(deny)
+
+	// Changes may first be made either now, when an allow window opens, or
+	// when a deny window closes.
+	candidates := []time.Time{now}
+	for _, iv := range allow {
+		if iv.start.After(now) {
+			candidates = append(candidates, iv.start)
+		}
+	}
+	for _, iv := range deny {
+		if iv.end.After(now) {
+			candidates = append(candidates, iv.end)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	for _, t := range candidates {
+		if t.After(to) {
+			break
+		}
+		if within(allow, t) && !within(deny, t) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// merge returns the supplied intervals with those that overlap merged, in
+// order.
+func merge(ivs []interval) []interval {
+	if len(ivs) == 0 {
+		return nil
+	}
+	sort.Slice(ivs, func(i, j int) bool { return ivs[i].start.Before(ivs[j].start) })
+	merged := []interval{ivs[0]}
+	for _, iv := range ivs[1:] {
+		last := &merged[len(merged)-1]
+		if iv.start.After(last.end) {
+			merged = append(merged, iv)
+			continue
+		}
+		if iv.end.After(last.end) {
+			last.end = iv.end
+		}
+	}
+	return merged
+}
+
+func within(ivs []interval, t time.Time) bool {
+	for _, iv := range ivs {
+		if iv.contains(t) {
+			return true
+		}
+	}
+	return false
+}
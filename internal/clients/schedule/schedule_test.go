@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+// monday is a Monday at noon.
+var monday = time.Date(2022, time.October, 3, 12, 0, 0, 0, time.UTC)
+
+func at(day, hour, min int) time.Time {
+	return time.Date(2022, time.October, 3+day, hour, min, 0, 0, time.UTC)
+}
+
+func TestNext(t *testing.T) {
+	nightly := v1alpha1.Window{Start: "0 22 * * 1-5", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+	office := v1alpha1.Window{Start: "0 9 * * 1-5", Duration: metav1.Duration{Duration: 8 * time.Hour}}
+
+	type want struct {
+		next time.Time
+		ok   bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		s      *v1alpha1.Schedules
+		now    time.Time
+		want   want
+	}{
+		"NoSchedules": {
+			reason: "Changes should be allowed at any time without schedules.",
+			now:    monday,
+			want:   want{next: monday, ok: true},
+		},
+		"InAllowWindow": {
+			reason: "Changes should be allowed in an allow window, including one that opened the day before.",
+			s:      &v1alpha1.Schedules{Allow: []v1alpha1.Window{nightly}},
+			now:    at(1, 1, 30),
+			want:   want{next: at(1, 1, 30), ok: true},
+		},
+		"BeforeAllowWindow": {
+			reason: "Changes should be deferred until an allow window opens.",
+			s:      &v1alpha1.Schedules{Allow: []v1alpha1.Window{nightly}},
+			now:    monday,
+			want:   want{next: at(0, 22, 0), ok: true},
+		},
+		"Weekend": {
+			reason: "Changes should be deferred over a weekend without allow windows.",
+			s:      &v1alpha1.Schedules{Allow: []v1alpha1.Window{nightly}},
+			now:    at(5, 12, 0),
+			want:   want{next: at(7, 22, 0), ok: true},
+		},
+		"InDenyWindow": {
+			reason: "Changes should be deferred until a deny window closes.",
+			s:      &v1alpha1.Schedules{Deny: []v1alpha1.Window{office}},
+			now:    monday,
+			want:   want{next: at(0, 17, 0), ok: true},
+		},
+		"DenyOverridesAllow": {
+			reason: "Deny windows should take precedence over allow windows.",
+			s: &v1alpha1.Schedules{
+				Allow: []v1alpha1.Window{{Start: "0 0 * * *", Duration: metav1.Duration{Duration: 24 * time.Hour}}},
+				Deny:  []v1alpha1.Window{office},
+			},
+			now:  monday,
+			want: want{next: at(0, 17, 0), ok: true},
+		},
+		"TimeZone": {
+			reason: "Windows should open in their time zone.",
+			s:      &v1alpha1.Schedules{Allow: []v1alpha1.Window{{Start: "0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Europe/Istanbul"}}},
+			now:    monday,
+			want:   want{next: at(0, 19, 0), ok: true},
+		},
+		"Never": {
+			reason: "Changes that are always denied should be deferred indefinitely.",
+			s:      &v1alpha1.Schedules{Deny: []v1alpha1.Window{{Start: "* * * * *", Duration: metav1.Duration{Duration: time.Hour}}}},
+			now:    monday,
+			want:   want{ok: false},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := New(tc.s)
+			if err != nil {
+				t.Fatalf("New(...): %v", err)
+			}
+			next, ok := s.Next(tc.now)
+			if diff := cmp.Diff(tc.want, want{next: next, ok: ok}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nNext(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// TestLoad reads a cmn_schedule with a maintenance window on weeknights, and
+// an exclusion for one of them, from a fake ServiceNow instance.
+func TestLoad(t *testing.T) {
+	sn := servicenow.NewServer()
+	defer sn.Close()
+
+	id := sn.Insert(servicenow.TableSchedule, servicenow.Record{"name": "Maintenance", fieldTimeZone: "Europe/Istanbul"})
+	sn.Insert(servicenow.TableScheduleSpan, servicenow.Record{"schedule": id, fieldStart: "20220905T230000", fieldEnd: "20220906T030000", fieldRepeatType: repeatWeekdays})
+	sn.Insert(servicenow.TableScheduleSpan, servicenow.Record{"schedule": id, fieldStart: "20221003T000000", fieldEnd: "20221005T000000", fieldType: typeExclude})
+
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name(),
+		Schedules: &v1alpha1.Schedules{ScheduleSysID: &id}}
+	s, err := Load(context.Background(), cfg, table.NewTableClient(cfg))
+	if err != nil {
+		t.Fatalf("Load(...): %v", err)
+	}
+
+	// Monday and Tuesday are excluded in Istanbul, so changes are deferred
+	// until the window of Tuesday night is no longer excluded, at midnight.
+	next, ok := s.Next(monday)
+	if diff := cmp.Diff(at(1, 21, 0), next); !ok || diff != "" {
+		t.Errorf("Next(...): -want, +got:\n%s\n", diff)
+	}
+}
+
+func TestGateCachesSchedule(t *testing.T) {
+	sn := servicenow.NewServer()
+	defer sn.Close()
+
+	id := sn.Insert(servicenow.TableSchedule, servicenow.Record{"name": "Maintenance", fieldTimeZone: "UTC"})
+	sn.Insert(servicenow.TableScheduleSpan, servicenow.Record{"schedule": id, fieldStart: "20221003T000000", fieldEnd: "20221004T000000"})
+
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name(), Version: "1",
+		Schedules: &v1alpha1.Schedules{ScheduleSysID: &id}}
+	defer ForgetProviderConfig(cfg.ProviderConfigName)
+	open := func(cfg clients.Config, now time.Time) error {
+		g := NewGate(cfg, table.NewTableClient(cfg))
+		g.now = func() time.Time { return now }
+		_, err := g.Open(context.Background(), &v1alpha1.ProviderConfig{})
+		return err
+	}
+
+	if err := open(cfg, monday); err != nil {
+		t.Fatalf("Open(...): %v", err)
+	}
+
+	sn.InjectFault(servicenow.StatusFault(http.StatusInternalServerError))
+	if err := open(cfg, monday.Add(time.Minute)); err != nil {
+		t.Errorf("Open(...): want the cached schedule to be used, got %v", err)
+	}
+	if err := open(cfg, monday.Add(scheduleTTL)); err == nil {
+		t.Errorf("Open(...): want the schedule to be read again once it expired")
+	}
+	cfg.Version = "2"
+	if err := open(cfg, monday.Add(time.Minute)); err == nil {
+		t.Errorf("Open(...): want the schedule to be read again for another version of the ProviderConfig")
+	}
+}
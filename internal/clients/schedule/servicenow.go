@@ -0,0 +1,218 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	sdkTable "github.com/anka-software/cmdb-sdk/pkg/client/table"
+
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+)
+
+const (
+	errGetSchedule      = "cannot get cmn_schedule with Table API"
+	errGetSpans         = "cannot get cmn_schedule_span with Table API"
+	errScheduleNotFound = "cmn_schedule does not exist"
+	errParseSpan        = "cannot parse cmn_schedule_span"
+	errRepeatType       = "unsupported repeat type"
+)
+
+// Fields and values of the entries of a cmn_schedule.
+const (
+	fieldTimeZone    = "time_zone"
+	fieldStart       = "start_date_time"
+	fieldEnd         = "end_date_time"
+	fieldRepeatType  = "repeat_type"
+	fieldRepeatCount = "repeat_count"
+	fieldRepeatUntil = "repeat_until"
+	fieldDaysOfWeek  = "days_of_week"
+	fieldType        = "type"
+
+	repeatNone     = ""
+	repeatDaily    = "daily"
+	repeatWeekdays = "weekdays"
+	repeatWeekends = "weekends"
+	repeatWeekly   = "weekly"
+
+	typeExclude = "exclude"
+
+	// layoutDateTime is the format of the start and end of an entry, which
+	// is in UTC if it ends with Z, or in the time zone of the schedule.
+	layoutDateTime = "20060102T150405"
+	layoutDate     = "20060102"
+)
+
+// A span is an entry of a cmn_schedule, which may repeat.
+type span struct {
+	start, end time.Time
+	repeat     string
+	count      int
+	days       string
+	until      time.Time
+}
+
+func (s *span) intervals(from, to time.Time) []interval {
+	d := s.end.Sub(s.start)
+	if s.repeat == repeatNone {
+		if s.start.Before(to) && s.end.After(from) {
+			return []interval{{start: s.start, end: s.end}}
+		}
+		return nil
+	}
+
+	var ivs []interval
+	// Start a day early, in case of a change to daylight saving time.
+	first := int(from.Add(-d).Sub(s.start).Hours()/24) - 1
+	if first < 0 {
+		first = 0
+	}
+	for k := first; ; k++ {
+		// Occurrences keep the wall clock time of the entry.
+		start := s.start.AddDate(0, 0, k)
+		if !start.Before(to) || (!s.until.IsZero() && start.After(s.until)) {
+			return ivs
+		}
+		if s.occurs(k, start.Weekday()) && start.Add(d).After(from) {
+			ivs = append(ivs, interval{start: start, end: start.Add(d)})
+		}
+	}
+}
+
+// occurs returns true if the entry occurs on the supplied day after its
+// first, which is the supplied day of the week.
+func (s *span) occurs(day int, wd time.Weekday) bool {
+	switch s.repeat {
+	case repeatDaily:
+		return day%s.count == 0
+	case repeatWeekdays:
+		return wd != time.Saturday && wd != time.Sunday
+	case repeatWeekends:
+		return wd == time.Saturday || wd == time.Sunday
+	case repeatWeekly:
+		days := s.days
+		if days == "" {
+			days = isoWeekday(s.start.Weekday())
+		}
+		return (day/7)%s.count == 0 && strings.Contains(days, isoWeekday(wd))
+	}
+	return false
+}
+
+// isoWeekday returns the day of the week as numbered by ServiceNow, from 1
+// for Monday to 7 for Sunday.
+func isoWeekday(wd time.Weekday) string {
+	if wd == time.Sunday {
+		return "7"
+	}
+	return strconv.Itoa(int(wd))
+}
+
+// parseSpan parses the supplied cmn_schedule_span record. It returns true if
+// the entry excludes its times from the schedule.
+func parseSpan(rec map[string]interface{}, loc *time.Location) (*span, bool, error) {
+	str := func(f string) string {
+		v, _ := rec[f].(string)
+		return v
+	}
+	start, err := parseDateTime(str(fieldStart), loc)
+	if err != nil {
+		return nil, false, errors.Wrap(err, errParseSpan)
+	}
+	end, err := parseDateTime(str(fieldEnd), loc)
+	if err != nil {
+		return nil, false, errors.Wrap(err, errParseSpan)
+	}
+	s := &span{start: start, end: end, repeat: str(fieldRepeatType), count: 1, days: str(fieldDaysOfWeek)}
+	switch s.repeat {
+	case repeatNone, repeatDaily, repeatWeekdays, repeatWeekends, repeatWeekly:
+	default:
+		return nil, false, errors.Errorf("%s %q", errRepeatType, s.repeat)
+	}
+	if c, err := strconv.Atoi(str(fieldRepeatCount)); err == nil && c > 0 {
+		s.count = c
+	}
+	if u := str(fieldRepeatUntil); u != "" {
+		until, err := time.ParseInLocation(layoutDate, u, loc)
+		if err != nil {
+			return nil, false, errors.Wrap(err, errParseSpan)
+		}
+		// The entry repeats until the end of the day.
+		s.until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return s, str(fieldType) == typeExclude, nil
+}
+
+func parseDateTime(v string, loc *time.Location) (time.Time, error) {
+	if strings.HasSuffix(v, "Z") {
+		return time.ParseInLocation(layoutDateTime, strings.TrimSuffix(v, "Z"), time.UTC)
+	}
+	return time.ParseInLocation(layoutDateTime, v, loc)
+}
+
+// Load returns the schedule of the supplied config, including the windows of
+// a referenced cmn_schedule, which are read with the supplied Table API
+// client.
+func Load(ctx context.Context, cfg clients.Config, t sdkTable.ClientService) (*Schedule, error) {
+	s, err := New(cfg.Schedules)
+	if err != nil || cfg.Schedules == nil || cfg.Schedules.ScheduleSysID == nil {
+		return s, err
+	}
+	id := *cfg.Schedules.ScheduleSysID
+
+	sch, err := t.GetTableItems(table.GenerateGetRecordOptions(ctx, table.TableSchedule, id),
+		table.WithReadOptions(table.ReadOptions{Fields: []string{fieldTimeZone}, Limit: 1}))
+	if err != nil {
+		return nil, errors.Wrap(clients.Annotate(err), errGetSchedule)
+	}
+	if len(sch.Payload.Result) == 0 {
+		return nil, errors.Errorf("%s: %s", errScheduleNotFound, id)
+	}
+	loc := time.UTC
+	if tz, _ := sch.Payload.Result[0][fieldTimeZone].(string); tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, errors.Wrap(err, errTimeZone)
+		}
+	}
+
+	// Spans are read in full, since a missing span would open or close a
+	// window it should not.
+	spans, err := table.ReadAll(t, table.GenerateGetScheduleSpansOptions(ctx, id), table.ReadOptions{
+		Fields: []string{fieldType, fieldStart, fieldEnd, fieldRepeatType, fieldRepeatCount, fieldRepeatUntil, fieldDaysOfWeek},
+	})
+	if err != nil {
+		return nil, errors.Wrap(clients.Annotate(err), errGetSpans)
+	}
+	for _, rec := range spans {
+		sp, exclude, err := parseSpan(rec, loc)
+		if err != nil {
+			return nil, err
+		}
+		if exclude {
+			s.deny = append(s.deny, sp)
+			continue
+		}
+		s.allow = append(s.allow, sp)
+	}
+	return s, nil
+}
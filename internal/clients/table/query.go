@@ -0,0 +1,282 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package table
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	errInvalidField    = "invalid query field"
+	errInvalidOperator = "invalid query operator"
+	errValueCount      = "wrong number of values for query operator"
+	errListValue       = "query values of IN and NOT IN must not contain commas"
+)
+
+// An Operator of a condition of an encoded query.
+type Operator string
+
+// Operators of encoded queries.
+const (
+	OpEquals          Operator = "="
+	OpNotEquals       Operator = "!="
+	OpLessThan        Operator = "<"
+	OpLessOrEquals    Operator = "<="
+	OpGreaterThan     Operator = ">"
+	OpGreaterOrEquals Operator = ">="
+	OpStartsWith      Operator = "STARTSWITH"
+	OpEndsWith        Operator = "ENDSWITH"
+	OpContains        Operator = "LIKE"
+	OpNotContains     Operator = "NOTLIKE"
+	OpIn              Operator = "IN"
+	OpNotIn           Operator = "NOT IN"
+	OpIsEmpty         Operator = "ISEMPTY"
+	OpIsNotEmpty      Operator = "ISNOTEMPTY"
+)
+
+// Separators and keywords of encoded queries.
+const (
+	sepAnd         = "^"
+	sepOr          = "^OR"
+	sepNewQuery    = "^NQ"
+	keyOrderBy     = "ORDERBY"
+	keyOrderByDesc = "ORDERBYDESC"
+)
+
+// field matches the names of fields, including dot-walked fields such as
+// assigned_to.name.
+var field = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)*$`)
+
+// A Condition of an encoded query.
+type Condition struct {
+	Field    string
+	Operator Operator
+
+	// Values compared with the field. IN and NOT IN take one or more
+	// values, ISEMPTY and ISNOTEMPTY none, and the other operators one.
+	Values []string
+}
+
+// Equals returns a condition that the supplied field equals the supplied
+// value.
+func Equals(field, value string) Condition {
+	return Condition{Field: field, Operator: OpEquals, Values: []string{value}}
+}
+
+// Compare returns a condition that compares the supplied field with the
+// supplied value.
+func Compare(field string, op Operator, value string) Condition {
+	return Condition{Field: field, Operator: op, Values: []string{value}}
+}
+
+// In returns a condition that the supplied field equals one of the supplied
+// values.
+func In(field string, values ...string) Condition {
+	return Condition{Field: field, Operator: OpIn, Values: values}
+}
+
+// IsEmpty returns a condition that the supplied field is empty.
+func IsEmpty(field string) Condition {
+	return Condition{Field: field, Operator: OpIsEmpty}
+}
+
+// IsNotEmpty returns a condition that the supplied field is not empty.
+func IsNotEmpty(field string) Condition {
+	return Condition{Field: field, Operator: OpIsNotEmpty}
+}
+
+// Validate returns an error if the condition cannot be encoded.
+func (c Condition) Validate() error {
+	if !field.MatchString(c.Field) {
+		return errors.Errorf("%s %q", errInvalidField, c.Field)
+	}
+	want := 1
+	switch c.Operator {
+	case OpEquals, OpNotEquals, OpLessThan, OpLessOrEquals, OpGreaterThan, OpGreaterOrEquals,
+		OpStartsWith, OpEndsWith, OpContains, OpNotContains:
+	case OpIn, OpNotIn:
+		if len(c.Values) == 0 {
+			return errors.Errorf("%s %s: %d", errValueCount, c.Operator, 0)
+		}
+		for _, v := range c.Values {
+			if strings.Contains(v, ",") {
+				return errors.New(errListValue)
+			}
+		}
+		return nil
+	case OpIsEmpty, OpIsNotEmpty:
+		want = 0
+	default:
+		return errors.Errorf("%s %q", errInvalidOperator, c.Operator)
+	}
+	if len(c.Values) != want {
+		return errors.Errorf("%s %s: %d", errValueCount, c.Operator, len(c.Values))
+	}
+	return nil
+}
+
+// String returns the condition as a term of an encoded query.
+func (c Condition) String() string {
+	values := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		values[i] = escape(v)
+	}
+	return c.Field + string(c.Operator) + strings.Join(values, ",")
+}
+
+// escape escapes the separators of encoded queries in a value. A caret is
+// escaped by doubling it, which also escapes ^OR and ^NQ.
+func escape(v string) string {
+	return strings.ReplaceAll(v, "^", "^^")
+}
+
+// A Query builds an encoded query: a disjunction of queries, each a
+// conjunction of disjunctions of conditions, and the order of the records.
+// For example NewQuery(Equals("a", "1")).Or(Equals("b", "2")).And(IsEmpty("c"))
+// is encoded as a=1^ORb=2^cISEMPTY.
+type Query struct {
+	queries [][][]Condition
+	orderBy []string
+}
+
+// NewQuery returns a query that all of the supplied conditions must match.
+func NewQuery(c ...Condition) *Query {
+	q := &Query{queries: [][][]Condition{nil}}
+	for _, cond := range c {
+		q.And(cond)
+	}
+	return q
+}
+
+// And adds a condition the records must match.
+func (q *Query) And(c Condition) *Query {
+	n := len(q.queries) - 1
+	q.queries[n] = append(q.queries[n], []Condition{c})
+	return q
+}
+
+// Or adds an alternative to the condition that was added last.
+func (q *Query) Or(c Condition) *Query {
+	n := len(q.queries) - 1
+	and := q.queries[n]
+	if len(and) == 0 {
+		return q.And(c)
+	}
+	and[len(and)-1] = append(and[len(and)-1], c)
+	return q
+}
+
+// NewQuery starts another query, whose records are returned in addition to
+// the records of the queries before it.
+func (q *Query) NewQuery(c ...Condition) *Query {
+	q.queries = append(q.queries, nil)
+	for _, cond := range c {
+		q.And(cond)
+	}
+	return q
+}
+
+// OrderBy orders the records by the supplied field, ascending.
+func (q *Query) OrderBy(field string) *Query {
+	q.orderBy = append(q.orderBy, keyOrderBy+field)
+	return q
+}
+
+// OrderByDesc orders the records by the supplied field, descending.
+func (q *Query) OrderByDesc(field string) *Query {
+	q.orderBy = append(q.orderBy, keyOrderByDesc+field)
+	return q
+}
+
+// Validate returns an error if any condition of the query cannot be encoded.
+func (q *Query) Validate() error {
+	for _, and := range q.queries {
+		for _, or := range and {
+			for _, c := range or {
+				if err := c.Validate(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, o := range q.orderBy {
+		f := strings.TrimPrefix(strings.TrimPrefix(o, keyOrderByDesc), keyOrderBy)
+		if !field.MatchString(f) {
+			return errors.Errorf("%s %q", errInvalidField, f)
+		}
+	}
+	return nil
+}
+
+// String returns the encoded query.
+func (q *Query) String() string {
+	queries := make([]string, 0, len(q.queries))
+	for _, and := range q.queries {
+		terms := make([]string, 0, len(and))
+		for _, or := range and {
+			alternatives := make([]string, len(or))
+			for i, c := range or {
+				alternatives[i] = c.String()
+			}
+			terms = append(terms, strings.Join(alternatives, sepOr))
+		}
+		queries = append(queries, strings.Join(terms, sepAnd))
+	}
+	s := strings.Join(queries, sepNewQuery)
+	for _, o := range q.orderBy {
+		if s != "" {
+			s += sepAnd
+		}
+		s += o
+	}
+	return s
+}
+
+// Ordered returns true if the supplied encoded query orders its records, that
+// is if any of its terms is an ORDERBY or ORDERBYDESC term. Values that
+// merely contain ORDERBY, e.g. short_descriptionLIKEORDERBY, do not order
+// the records.
+func Ordered(query string) bool {
+	for _, t := range terms(query) {
+		if strings.HasPrefix(t, keyOrderByDesc) || strings.HasPrefix(t, keyOrderBy) {
+			return true
+		}
+	}
+	return false
+}
+
+// terms splits an encoded query into its terms. A doubled caret is a caret of
+// a value, not a separator.
+func terms(query string) []string {
+	var ts []string
+	start := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] != '^' {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == '^' {
+			i++
+			continue
+		}
+		ts = append(ts, query[start:i])
+		start = i + 1
+	}
+	return append(ts, query[start:])
+}
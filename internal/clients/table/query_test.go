@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package table
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+func TestQuery(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		q      *Query
+		want   string
+		err    error
+	}{
+		"Empty": {
+			reason: "A query without conditions should match every record.",
+			q:      NewQuery(),
+			want:   "",
+		},
+		"And": {
+			reason: "Conditions should be joined by ^.",
+			q:      NewQuery(Equals("name", "web-1"), IsNotEmpty("ip_address")),
+			want:   "name=web-1^ip_addressISNOTEMPTY",
+		},
+		"Or": {
+			reason: "Alternatives should be joined to the last condition by ^OR.",
+			q:      NewQuery(Equals("a", "1")).Or(Equals("b", "2")).And(IsEmpty("c")),
+			want:   "a=1^ORb=2^cISEMPTY",
+		},
+		"NewQuery": {
+			reason: "Queries should be joined by ^NQ, and followed by their order.",
+			q:      NewQuery(Compare("name", OpStartsWith, "web")).NewQuery(In("sys_class_name", "cmdb_ci_linux_server", "cmdb_ci_win_server")).OrderByDesc("sys_updated_on"),
+			want:   "nameSTARTSWITHweb^NQsys_class_nameINcmdb_ci_linux_server,cmdb_ci_win_server^ORDERBYDESCsys_updated_on",
+		},
+		"Escaped": {
+			reason: "Carets of values should be doubled, so that they cannot end a condition.",
+			q:      NewQuery(Equals("name", "a^NQname=b^ORc")),
+			want:   "name=a^^NQname=b^^ORc",
+		},
+		"InvalidField": {
+			reason: "Fields that are not names of fields should be rejected.",
+			q:      NewQuery(Equals("name=x^ORname", "y")),
+			err:    errors.Errorf("%s %q", errInvalidField, "name=x^ORname"),
+		},
+		"InvalidOrderBy": {
+			reason: "Order fields that are not names of fields should be rejected.",
+			q:      NewQuery().OrderBy("name^NQ"),
+			err:    errors.Errorf("%s %q", errInvalidField, "name^NQ"),
+		},
+		"InvalidOperator": {
+			reason: "Unknown operators should be rejected.",
+			q:      NewQuery(Compare("name", "SAMEAS", "x")),
+			err:    errors.Errorf("%s %q", errInvalidOperator, "SAMEAS"),
+		},
+		"ValueCount": {
+			reason: "Operators should be given the number of values they take.",
+			q:      NewQuery(Condition{Field: "name", Operator: OpIsEmpty, Values: []string{"x"}}),
+			err:    errors.Errorf("%s %s: %d", errValueCount, OpIsEmpty, 1),
+		},
+		"EmptyIn": {
+			reason: "IN without values should be rejected, since it would match no record.",
+			q:      NewQuery(In("name")),
+			err:    errors.Errorf("%s %s: %d", errValueCount, OpIn, 0),
+		},
+		"EmptyNotIn": {
+			reason: "NOT IN without values should be rejected.",
+			q:      NewQuery(Condition{Field: "name", Operator: OpNotIn}),
+			err:    errors.Errorf("%s %s: %d", errValueCount, OpNotIn, 0),
+		},
+		"ListValue": {
+			reason: "Values of IN that contain commas should be rejected, since they cannot be escaped.",
+			q:      NewQuery(In("name", "a,b")),
+			err:    errors.New(errListValue),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.q.Validate()
+			if diff := cmp.Diff(tc.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nq.Validate(): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want, tc.q.String()); diff != "" {
+				t.Errorf("\n%s\nq.String(): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		query  string
+		want   bool
+	}{
+		"Empty": {
+			reason: "An empty query should not order its records.",
+			query:  "",
+			want:   false,
+		},
+		"OrderBy": {
+			reason: "A query with an ORDERBY term should order its records.",
+			query:  "name=web-1^ORDERBYname",
+			want:   true,
+		},
+		"OrderByDesc": {
+			reason: "A query with an ORDERBYDESC term should order its records.",
+			query:  "ORDERBYDESCsys_updated_on",
+			want:   true,
+		},
+		"Value": {
+			reason: "A value that contains ORDERBY should not order the records.",
+			query:  "short_descriptionLIKEORDERBY",
+			want:   false,
+		},
+		"EscapedValue": {
+			reason: "A value that contains an escaped caret followed by ORDERBY should not order the records.",
+			query:  "short_description=a^^ORDERBYname",
+			want:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, Ordered(tc.query)); diff != "" {
+				t.Errorf("\n%s\nOrdered(%q): -want, +got:\n%s\n", tc.reason, tc.query, diff)
+			}
+		})
+	}
+}
+
+func TestGenerateGetTableItemsOptionsEscaped(t *testing.T) {
+	const class = "cmdb_ci_appl"
+
+	sn := servicenow.NewServer()
+	defer sn.Close()
+	want := sn.Insert(class, servicenow.Record{"name": "app^NQname=other"})
+	sn.Insert(class, servicenow.Record{"name": "other"})
+
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+	records, err := ReadAll(NewTableClient(cfg), GenerateGetTableItemsOptions(context.Background(), class, "app^NQname=other"), ReadOptions{})
+	if err != nil {
+		t.Fatalf("ReadAll(...): %v", err)
+	}
+	var got []string
+	for _, r := range records {
+		got = append(got, FieldValue(r["sys_id"]))
+	}
+	if diff := cmp.Diff([]string{want}, got); diff != "" {
+		t.Errorf("GenerateGetTableItemsOptions(...): a name with separators should only match itself: -want, +got:\n%s\n", diff)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package table
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/pkg/errors"
+
+	"github.com/anka-software/cmdb-sdk/pkg/client/table"
+)
+
+// DefaultPageSize is the number of records a Table API read returns per
+// page unless ReadOptions ask for another.
+const DefaultPageSize = 1000
+
+// MaxRecords is the number of records ReadAll reads at most, so that a query
+// that matches a whole table does not exhaust the memory of the provider.
+const MaxRecords = 100000
+
+const errTooManyRecords = "query matches more records than can be read, narrow it or set a limit; maximum is"
+
+const (
+	paramFields               = "sysparm_fields"
+	paramLimit                = "sysparm_limit"
+	paramOffset               = "sysparm_offset"
+	paramDisplayValue         = "sysparm_display_value"
+	paramExcludeReferenceLink = "sysparm_exclude_reference_link"
+
+	headerLink = "Link"
+)
+
+// linkNext matches the next page of a Link header, e.g.
+// <https://example.service-now.com/api/now/table/cmdb_ci?sysparm_offset=1000&sysparm_limit=1000>;rel="next".
+var linkNext = regexp.MustCompile(`<([^>]*)>;\s*rel="next"`)
+
+// ReadOptions select the records and fields a Table API read returns.
+type ReadOptions struct {
+	// Fields of the records that are returned. Every field is returned if
+	// none are supplied.
+	Fields []string
+
+	// PageSize is the number of records returned per page. Defaults to
+	// DefaultPageSize.
+	PageSize int
+
+	// Limit is the number of records returned in total. Every record is
+	// returned if it is not positive.
+	Limit int
+
+	// DisplayValue returns the display values of fields instead of their
+	// values, e.g. the name of the record a reference field refers to
+	// instead of its sys_id.
+	DisplayValue bool
+
+	// ExcludeReferenceLink returns reference fields as the sys_id they
+	// refer to instead of an object with a link to the record.
+	ExcludeReferenceLink bool
+}
+
+// pageSize returns the number of records to read per page.
+func (o ReadOptions) pageSize() int {
+	n := o.PageSize
+	if n <= 0 {
+		n = DefaultPageSize
+	}
+	if o.Limit > 0 && o.Limit < n {
+		n = o.Limit
+	}
+	return n
+}
+
+// WithReadOptions reads the first page of the records of a GetTableItems
+// request as the supplied options ask. Use ReadAll to read every page.
+func WithReadOptions(o ReadOptions) table.ClientOption {
+	return func(op *runtime.ClientOperation) {
+		params := map[string]string{
+			paramLimit:        strconv.Itoa(o.pageSize()),
+			paramDisplayValue: strconv.FormatBool(o.DisplayValue),
+		}
+		if len(o.Fields) > 0 {
+			params[paramFields] = strings.Join(o.Fields, ",")
+		}
+		if o.ExcludeReferenceLink {
+			params[paramExcludeReferenceLink] = "true"
+		}
+		op.Params = &paramsWriter{params: op.Params, query: params}
+	}
+}
+
+// withPage reads the page of the records of a GetTableItems request at the
+// supplied offset, and records the URL of the next page, if any.
+func withPage(offset int, next *string) table.ClientOption {
+	return func(op *runtime.ClientOperation) {
+		op.Params = &paramsWriter{params: op.Params, query: map[string]string{paramOffset: strconv.Itoa(offset)}}
+		op.Reader = &linkReader{reader: op.Reader, next: next}
+	}
+}
+
+// ReadAll reads every page of the records of a GetTableItems request, up to
+// the limit of the supplied options, by following the next links of the
+// responses. It returns an error if more than MaxRecords records would be
+// read. Records of queries that do not order them are ordered by sys_id, so
+// that no record moves from one page to another between reads.
+func ReadAll(t table.ClientService, params *table.GetTableItemsParams, o ReadOptions) ([]map[string]interface{}, error) {
+	return readAll(t, params, o, MaxRecords)
+}
+
+func readAll(t table.ClientService, params *table.GetTableItemsParams, o ReadOptions, max int) ([]map[string]interface{}, error) {
+	params = ordered(params)
+	var records []map[string]interface{}
+	offset := 0
+	for {
+		next := ""
+		response, err := t.GetTableItems(params, WithReadOptions(o), withPage(offset, &next))
+		if err != nil {
+			return nil, err
+		}
+		if response.Payload != nil {
+			records = append(records, response.Payload.Result...)
+		}
+		if o.Limit > 0 && len(records) >= o.Limit {
+			return records[:o.Limit], nil
+		}
+		n, ok := nextOffset(next)
+		// A next link that does not move forward would never end.
+		more := ok && n > offset
+		if len(records) > max || (more && len(records) >= max) {
+			return nil, errors.Errorf("%s %d", errTooManyRecords, max)
+		}
+		if !more {
+			return records, nil
+		}
+		offset = n
+	}
+}
+
+// ordered returns the supplied parameters, with their query ordered by sys_id
+// unless it orders its records.
+func ordered(params *table.GetTableItemsParams) *table.GetTableItemsParams {
+	query := ""
+	if params.Query != nil {
+		query = *params.Query
+	}
+	if Ordered(query) {
+		return params
+	}
+	if query != "" {
+		query += sepAnd
+	}
+	query += keyOrderBy + fieldSysID
+	p := *params
+	p.Query = &query
+	return &p
+}
+
+// nextOffset returns the offset of the supplied next page URL.
+func nextOffset(next string) (int, bool) {
+	if next == "" {
+		return 0, false
+	}
+	u, err := url.Parse(next)
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(u.Query().Get(paramOffset))
+	return n, err == nil
+}
+
+// A paramsWriter writes a request, and then sets the supplied query
+// parameters, replacing those the request set.
+type paramsWriter struct {
+	params runtime.ClientRequestWriter
+	query  map[string]string
+}
+
+func (w *paramsWriter) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+	if err := w.params.WriteToRequest(r, reg); err != nil {
+		return err
+	}
+	for k, v := range w.query {
+		if err := r.SetQueryParam(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A linkReader records the URL of the next page of a response, which is read
+// by the reader of the SDK.
+type linkReader struct {
+	reader runtime.ClientResponseReader
+	next   *string
+}
+
+func (r *linkReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	if m := linkNext.FindStringSubmatch(response.GetHeader(headerLink)); m != nil {
+		*r.next = m[1]
+	}
+	return r.reader.ReadResponse(response, consumer)
+}
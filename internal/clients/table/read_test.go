@@ -0,0 +1,242 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package table
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+func TestReadAll(t *testing.T) {
+	const tableChoice = "sys_choice"
+
+	sn := servicenow.NewServer(servicenow.WithTable(tableChoice))
+	defer sn.Close()
+	for n := 1; n <= 5; n++ {
+		sn.Insert(tableChoice, servicenow.Record{"element": "category", "value": fmt.Sprintf("c%d", n), "label": fmt.Sprintf("C%d", n)})
+	}
+	sn.Insert(tableChoice, servicenow.Record{"element": "subcategory", "value": "s1"})
+
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+
+	type want struct {
+		values   []string
+		offsets  []string
+		selected string
+	}
+
+	cases := map[string]struct {
+		reason string
+		o      ReadOptions
+		want   want
+	}{
+		"AllPages": {
+			reason: "Every page should be read by following the next links.",
+			o:      ReadOptions{PageSize: 2, Fields: []string{"sys_id", "value"}},
+			want: want{
+				values:   []string{"c1", "c2", "c3", "c4", "c5"},
+				offsets:  []string{"0", "2", "4"},
+				selected: "sys_id,value",
+			},
+		},
+		"Limit": {
+			reason: "No more records than the limit should be read.",
+			o:      ReadOptions{PageSize: 2, Limit: 3},
+			want: want{
+				values:  []string{"c1", "c2", "c3"},
+				offsets: []string{"0", "2"},
+			},
+		},
+		"SinglePage": {
+			reason: "A limit smaller than the page size should be read in a single page.",
+			o:      ReadOptions{Limit: 1},
+			want: want{
+				values:  []string{"c1"},
+				offsets: []string{"0"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sn.Reset()
+			records, err := ReadAll(NewTableClient(cfg), GenerateQueryOptions(context.Background(), tableChoice, "element=category^ORDERBYvalue"), tc.o)
+			if err != nil {
+				t.Fatalf("\n%s\nReadAll(...): %v", tc.reason, err)
+			}
+
+			var values []string
+			for _, r := range records {
+				values = append(values, FieldValue(r["value"]))
+				if tc.o.Fields != nil && r["label"] != nil {
+					t.Errorf("\n%s\nReadAll(...): want only the selected fields, got %v", tc.reason, r)
+				}
+			}
+			if diff := cmp.Diff(tc.want.values, values); diff != "" {
+				t.Errorf("\n%s\nReadAll(...): -want values, +got values:\n%s\n", tc.reason, diff)
+			}
+
+			var offsets []string
+			for _, r := range sn.Requests() {
+				q, _ := url.ParseQuery(r.RawQuery)
+				offsets = append(offsets, q.Get(paramOffset))
+				if q.Get(paramFields) != tc.want.selected {
+					t.Errorf("\n%s\nReadAll(...): want fields %q, got %q", tc.reason, tc.want.selected, q.Get(paramFields))
+				}
+			}
+			if diff := cmp.Diff(tc.want.offsets, offsets); diff != "" {
+				t.Errorf("\n%s\nReadAll(...): -want page offsets, +got page offsets:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestReadAllOrdered(t *testing.T) {
+	const tableChoice = "sys_choice"
+
+	sn := servicenow.NewServer(servicenow.WithTable(tableChoice))
+	defer sn.Close()
+
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+
+	cases := map[string]struct {
+		reason string
+		query  string
+		want   string
+	}{
+		"Empty": {
+			reason: "The records of an empty query should be ordered by sys_id.",
+			query:  "",
+			want:   "ORDERBYsys_id",
+		},
+		"Unordered": {
+			reason: "The records of a query that does not order them should be ordered by sys_id, so that they do not move between pages.",
+			query:  "element=category",
+			want:   "element=category^ORDERBYsys_id",
+		},
+		"Ordered": {
+			reason: "A query that orders its records should be read as is.",
+			query:  "element=category^ORDERBYDESCvalue",
+			want:   "element=category^ORDERBYDESCvalue",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sn.Reset()
+			params := GenerateQueryOptions(context.Background(), tableChoice, tc.query)
+			if _, err := ReadAll(NewTableClient(cfg), params, ReadOptions{}); err != nil {
+				t.Fatalf("\n%s\nReadAll(...): %v", tc.reason, err)
+			}
+			for _, r := range sn.Requests() {
+				q, _ := url.ParseQuery(r.RawQuery)
+				if diff := cmp.Diff(tc.want, q.Get("sysparm_query")); diff != "" {
+					t.Errorf("\n%s\nReadAll(...): -want query, +got query:\n%s\n", tc.reason, diff)
+				}
+			}
+			if tc.query != "" && *params.Query != tc.query {
+				t.Errorf("\n%s\nReadAll(...): want the query of the supplied parameters unchanged, got %q", tc.reason, *params.Query)
+			}
+		})
+	}
+}
+
+func TestReadAllTooManyRecords(t *testing.T) {
+	const tableChoice = "sys_choice"
+
+	sn := servicenow.NewServer(servicenow.WithTable(tableChoice))
+	defer sn.Close()
+	for n := 1; n <= 5; n++ {
+		sn.Insert(tableChoice, servicenow.Record{"element": "category", "value": fmt.Sprintf("c%d", n)})
+	}
+
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+
+	cases := map[string]struct {
+		reason string
+		o      ReadOptions
+		max    int
+		want   error
+	}{
+		"TooMany": {
+			reason: "Reading more records than the maximum should return an error.",
+			o:      ReadOptions{PageSize: 2},
+			max:    4,
+			want:   errors.Errorf("%s %d", errTooManyRecords, 4),
+		},
+		"Maximum": {
+			reason: "Reading as many records as the maximum should succeed.",
+			o:      ReadOptions{PageSize: 2},
+			max:    5,
+		},
+		"Limit": {
+			reason: "A limit up to the maximum should be read.",
+			o:      ReadOptions{PageSize: 2, Limit: 4},
+			max:    4,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := readAll(NewTableClient(cfg), GenerateQueryOptions(context.Background(), tableChoice, "element=category"), tc.o, tc.max)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nreadAll(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRecordWithReadOptions(t *testing.T) {
+	const tableChoice = "sys_choice"
+
+	sn := servicenow.NewServer(servicenow.WithTable(tableChoice))
+	defer sn.Close()
+	id := sn.Insert(tableChoice, servicenow.Record{"element": "category", "value": "c1"})
+
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+	o := WithReadOptions(ReadOptions{Fields: []string{"sys_id"}})
+	tc := NewTableClient(cfg)
+
+	if _, err := tc.GetTableItems(GenerateInsertRecordOptions(context.Background(), tableChoice), o, WithRecord(map[string]string{"value": "c2"})); err != nil {
+		t.Fatalf("GetTableItems(...): insert: %v", err)
+	}
+	if _, err := tc.GetTableItems(GenerateUpdateRecordOptions(context.Background(), tableChoice), o, WithUpdatedRecord(id, map[string]string{"value": "c3"})); err != nil {
+		t.Fatalf("GetTableItems(...): update: %v", err)
+	}
+
+	var methods []string
+	for _, r := range sn.Requests() {
+		methods = append(methods, r.Method)
+	}
+	if diff := cmp.Diff([]string{http.MethodPost, http.MethodPatch}, methods); diff != "" {
+		t.Errorf("GetTableItems(...): want records to be written after WithReadOptions: -want methods, +got methods:\n%s\n", diff)
+	}
+	if r, _ := sn.Get(id); r["value"] != "c3" {
+		t.Errorf("GetTableItems(...): want the record to be updated, got %v", r)
+	}
+}
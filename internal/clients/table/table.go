@@ -17,11 +17,43 @@ limitations under the License.
 package table
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+
 	"github.com/anka-software/cmdb-sdk/pkg/client/table"
+	"github.com/anka-software/cmdb-sdk/pkg/models"
 
 	"github.com/crossplane/provider-cmdb/internal/clients"
 )
 
+// Tables of the schedules of an instance.
+const (
+	TableSchedule     = "cmn_schedule"
+	TableScheduleSpan = "cmn_schedule_span"
+)
+
+const (
+	tableSysProperties = "sys_properties"
+
+	fieldName     = "name"
+	fieldSysID    = "sys_id"
+	fieldSchedule = "schedule"
+
+	propertyBuildName = "glide.buildname"
+	propertyBuildTag  = "glide.buildtag"
+)
+
+// InstanceInfo describes the release of a ServiceNow instance.
+type InstanceInfo struct {
+	Version string
+	Build   string
+}
+
 // NewTableClient returns a new Table service
 func NewTableClient(cfg clients.Config) table.ClientService {
 	cmdbConfig := clients.NewClient(cfg)
@@ -30,12 +62,178 @@ func NewTableClient(cfg clients.Config) table.ClientService {
 }
 
 // GenerateGetTableItemsOptions get items.
-func GenerateGetTableItemsOptions(tableName string, ciName string) *table.GetTableItemsParams {
-	var query = "name=" + ciName
+func GenerateGetTableItemsOptions(ctx context.Context, tableName string, ciName string) *table.GetTableItemsParams {
+	var query = NewQuery(Equals(fieldName, ciName)).String()
+
+	var params = table.NewGetTableItemParams().WithContext(ctx).WithTableName(
+		tableName).WithQuery(
+		&query)
+
+	return params
+}
+
+// GenerateGetInstanceInfoOptions get the system properties that describe the
+// release of the instance. It is cheap enough to be used as a health probe.
+func GenerateGetInstanceInfoOptions(ctx context.Context) *table.GetTableItemsParams {
+	var query = NewQuery(In(fieldName, propertyBuildName, propertyBuildTag)).String()
+
+	var params = table.NewGetTableItemParams().WithContext(ctx).WithTableName(
+		tableSysProperties).WithQuery(
+		&query)
+
+	return params
+}
+
+// GetInstanceInfo extracts the instance release from the system properties
+// returned for GenerateGetInstanceInfoOptions. Properties the user is not
+// allowed to read are left empty.
+func GetInstanceInfo(items []map[string]interface{}) InstanceInfo {
+	info := InstanceInfo{}
+	for _, item := range items {
+		name, _ := item["name"].(string)
+		value, _ := item["value"].(string)
+		switch name {
+		case propertyBuildName:
+			info.Version = value
+		case propertyBuildTag:
+			info.Build = value
+		}
+	}
+	return info
+}
+
+// GenerateGetRecordOptions get the record with the supplied sys_id.
+func GenerateGetRecordOptions(ctx context.Context, tableName string, sysID string) *table.GetTableItemsParams {
+	var query = NewQuery(Equals(fieldSysID, sysID)).String()
 
-	var params = table.NewGetTableItemParams().WithTableName(
+	var params = table.NewGetTableItemParams().WithContext(ctx).WithTableName(
 		tableName).WithQuery(
 		&query)
 
 	return params
 }
+
+// GenerateQueryOptions get the records of the supplied table that match the
+// supplied encoded query, or every record if it is empty.
+func GenerateQueryOptions(ctx context.Context, tableName string, query string) *table.GetTableItemsParams {
+	var params = table.NewGetTableItemParams().WithContext(ctx).WithTableName(
+		tableName)
+	if query != "" {
+		params.SetQuery(&query)
+	}
+
+	return params
+}
+
+// GenerateGetScheduleSpansOptions get the entries of the cmn_schedule with the
+// supplied sys_id.
+func GenerateGetScheduleSpansOptions(ctx context.Context, scheduleSysID string) *table.GetTableItemsParams {
+	var query = NewQuery(Equals(fieldSchedule, scheduleSysID)).String()
+
+	var params = table.NewGetTableItemParams().WithContext(ctx).WithTableName(
+		TableScheduleSpan).WithQuery(
+		&query)
+
+	return params
+}
+
+// GenerateInsertRecordOptions insert a record into the supplied table. The
+// values of the record are sent with WithRecord.
+func GenerateInsertRecordOptions(ctx context.Context, tableName string) *table.GetTableItemsParams {
+	return table.NewGetTableItemParams().WithContext(ctx).WithTableName(tableName)
+}
+
+// GenerateUpdateRecordOptions update a record of the supplied table. The
+// sys_id and values of the record are sent with WithUpdatedRecord.
+func GenerateUpdateRecordOptions(ctx context.Context, tableName string) *table.GetTableItemsParams {
+	return table.NewGetTableItemParams().WithContext(ctx).WithTableName(tableName)
+}
+
+// GenerateDeleteRecordOptions delete the record of the supplied table with the
+// supplied sys_id.
+func GenerateDeleteRecordOptions(ctx context.Context, tableName string, sysID string) *table.DeleteRecordParams {
+	return table.NewDeleteRecordParams().WithContext(ctx).WithTableName(tableName).WithSysID(sysID)
+}
+
+// FieldValue returns the value of a field of a record. Reference fields are
+// returned as objects with a link unless the request excludes it, in which
+// case the sys_id they reference is returned.
+func FieldValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		s, _ := t["value"].(string)
+		return s
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// WithRecord inserts a record with the supplied values into the table of a
+// GetTableItems request, and returns the inserted record as its only result.
+// The SDK cannot insert records, so the request is rewritten as a POST. It
+// may be combined with WithReadOptions in either order.
+func WithRecord(values map[string]string) table.ClientOption {
+	return func(op *runtime.ClientOperation) {
+		op.ID = "insertRecord"
+		op.Method = http.MethodPost
+		op.Params = &recordWriter{params: op.Params, values: values}
+		op.Reader = &recordReader{reader: op.Reader}
+	}
+}
+
+// WithUpdatedRecord updates the supplied values of the record with the
+// supplied sys_id in the table of a GetTableItems request, and returns the
+// updated record as its only result. The SDK cannot update records, so the
+// request is rewritten as a PATCH. It may be combined with WithReadOptions in
+// either order.
+func WithUpdatedRecord(sysID string, values map[string]string) table.ClientOption {
+	return func(op *runtime.ClientOperation) {
+		op.ID = "updateRecord"
+		op.Method = http.MethodPatch
+		op.PathPattern = "/table/{tableName}/{sys_id}"
+		op.Params = &recordWriter{params: op.Params, sysID: sysID, values: values}
+		op.Reader = &recordReader{reader: op.Reader}
+	}
+}
+
+// A recordWriter writes a request that inserts a record, or updates the
+// record with its sys_id.
+type recordWriter struct {
+	params runtime.ClientRequestWriter
+	sysID  string
+	values map[string]string
+}
+
+func (w *recordWriter) WriteToRequest(r runtime.ClientRequest, reg strfmt.Registry) error {
+	if err := w.params.WriteToRequest(r, reg); err != nil {
+		return err
+	}
+	if w.sysID != "" {
+		if err := r.SetPathParam("sys_id", w.sysID); err != nil {
+			return err
+		}
+	}
+	return r.SetBodyParam(w.values)
+}
+
+// A recordReader reads the record returned by a request that inserted or
+// updated it. Errors are read by the reader of the SDK.
+type recordReader struct {
+	reader runtime.ClientResponseReader
+}
+
+func (r *recordReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	if response.Code() != http.StatusCreated && response.Code() != http.StatusOK {
+		return r.reader.ReadResponse(response, consumer)
+	}
+	inserted := struct {
+		Result map[string]interface{} `json:"result"`
+	}{}
+	if err := consumer.Consume(response.Body(), &inserted); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return &table.GetTableItemsOK{Payload: &models.GetTableItem{Result: []map[string]interface{}{inserted.Result}}}, nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clients
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transactionIDHeader identifies a request in the transaction logs of the
+// ServiceNow instance.
+const transactionIDHeader = "X-Transaction-ID"
+
+// A tracingTransport records every request sent to ServiceNow as an event
+// of the span of its context, if any.
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip sends the request and records it on the span of its context.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(req.Context())
+	if !span.IsRecording() {
+		return t.next.RoundTrip(req)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("http.method", req.Method),
+		attribute.String("http.target", req.URL.Path),
+	}
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		span.AddEvent("ServiceNow request failed", trace.WithAttributes(append(attrs, attribute.String("error", err.Error()))...))
+		return res, err
+	}
+
+	attrs = append(attrs, attribute.Int("http.status_code", res.StatusCode))
+	if id := res.Header.Get(transactionIDHeader); id != "" {
+		attrs = append(attrs, attribute.String("servicenow.transaction_id", id))
+		span.SetAttributes(attribute.String("servicenow.transaction_id", id))
+	}
+	span.AddEvent("ServiceNow request", trace.WithAttributes(attrs...))
+	return res, nil
+}
@@ -23,6 +23,8 @@ import (
 
 	"github.com/crossplane/provider-cmdb/internal/controller/config"
 	"github.com/crossplane/provider-cmdb/internal/controller/idenrecon"
+	"github.com/crossplane/provider-cmdb/internal/controller/inventory"
+	"github.com/crossplane/provider-cmdb/internal/controller/table"
 )
 
 // Setup creates all CMDB controllers with the supplied logger and adds them to
@@ -31,6 +33,8 @@ func Setup(mgr ctrl.Manager, o controller.Options) error {
 	for _, setup := range []func(ctrl.Manager, controller.Options) error{
 		config.Setup,
 		idenrecon.Setup,
+		inventory.Setup,
+		table.Setup,
 	} {
 		if err := setup(mgr, o); err != nil {
 			return err
@@ -0,0 +1,253 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/feature"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
+
+	"github.com/crossplane/provider-cmdb/apis"
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+const (
+	envtestTimeout  = 30 * time.Second
+	envtestInterval = 250 * time.Millisecond
+
+	testNamespace = "default"
+)
+
+// TestLifecycle runs the controllers against a real API server, started by
+// envtest, and a fake ServiceNow instance. It is skipped unless the
+// KUBEBUILDER_ASSETS environment variable points to the envtest binaries,
+// which 'make test-envtest' downloads and sets.
+func TestLifecycle(t *testing.T) { //nolint:gocyclo // A linear walk through the lifecycle of a CI.
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set, skipping envtest suite")
+	}
+
+	env := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "package", "crds")},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("env.Start(): %v", err)
+	}
+	defer func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("env.Stop(): %v", err)
+		}
+	}()
+
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatalf("clientgoscheme.AddToScheme(...): %v", err)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatalf("apis.AddToScheme(...): %v", err)
+	}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{Scheme: s, MetricsBindAddress: "0"})
+	if err != nil {
+		t.Fatalf("ctrl.NewManager(...): %v", err)
+	}
+	o := controller.Options{
+		Logger:                  logging.NewNopLogger(),
+		MaxConcurrentReconciles: 1,
+		PollInterval:            time.Second,
+		GlobalRateLimiter:       ratelimiter.NewGlobal(10),
+		Features:                &feature.Flags{},
+	}
+	if err := Setup(mgr, o); err != nil {
+		t.Fatalf("Setup(...): %v", err)
+	}
+
+	// The manager reports its error back to the test goroutine, since
+	// t.Errorf must not be called once the test has returned.
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan error, 1)
+	go func() { started <- mgr.Start(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-started; err != nil {
+			t.Errorf("mgr.Start(...): %v", err)
+		}
+	}()
+
+	sn := servicenow.NewServer()
+	defer sn.Close()
+
+	kube, err := client.New(cfg, client.Options{Scheme: s})
+	if err != nil {
+		t.Fatalf("client.New(...): %v", err)
+	}
+
+	// A ProviderConfig pointing to the fake instance should become ready.
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: "servicenow-creds"},
+		Data:       map[string][]byte{"password": []byte(servicenow.DefaultPassword)},
+	}
+	mustCreate(ctx, t, kube, secret)
+
+	pc := &apisv1alpha1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: apisv1alpha1.ProviderConfigSpec{
+			BaseURL:  sn.URL,
+			Username: servicenow.DefaultUsername,
+			Credentials: apisv1alpha1.ProviderCredentials{
+				Source: xpv1.CredentialsSourceSecret,
+				CommonCredentialSelectors: xpv1.CommonCredentialSelectors{SecretRef: &xpv1.SecretKeySelector{
+					SecretReference: xpv1.SecretReference{Namespace: testNamespace, Name: secret.GetName()},
+					Key:             "password",
+				}},
+			},
+		},
+	}
+	mustCreate(ctx, t, kube, pc)
+	eventually(t, "ProviderConfig to become ready", func() (bool, error) {
+		got := &apisv1alpha1.ProviderConfig{}
+		if err := kube.Get(ctx, types.NamespacedName{Name: pc.GetName()}, got); err != nil {
+			return false, err
+		}
+		return got.GetCondition(xpv1.TypeReady).Status == corev1.ConditionTrue && got.Status.Instance.Version != "", nil
+	})
+
+	// A CI should be created in ServiceNow, become ready and synced, and
+	// be named after the sys_id of the created record.
+	cr := &v1alpha1.CI{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1"},
+		Spec: v1alpha1.CISpec{
+			ResourceSpec: xpv1.ResourceSpec{
+				ProviderConfigReference: &xpv1.Reference{Name: pc.GetName()},
+				WriteConnectionSecretToReference: &xpv1.SecretReference{
+					Namespace: testNamespace,
+					Name:      "web-1-connection",
+				},
+			},
+			ForProvider: v1alpha1.CIParameters{
+				SysParamDataSource: "ServiceNow",
+				ClassName:          "cmdb_ci_linux_server",
+				Name:               "web-1",
+				Values:             map[string]string{"ram": "2048"},
+			},
+		},
+	}
+	mustCreate(ctx, t, kube, cr)
+
+	var sysID string
+	eventually(t, "CI to become ready and synced", func() (bool, error) {
+		got := &v1alpha1.CI{}
+		if err := kube.Get(ctx, types.NamespacedName{Name: cr.GetName()}, got); err != nil {
+			return false, err
+		}
+		sysID = meta.GetExternalName(got)
+		return got.GetCondition(xpv1.TypeReady).Status == corev1.ConditionTrue &&
+			got.GetCondition(xpv1.TypeSynced).Status == corev1.ConditionTrue, nil
+	})
+	record, ok := sn.Get(sysID)
+	if !ok {
+		t.Fatalf("CI external name %q is not the sys_id of a ServiceNow record", sysID)
+	}
+	if record["ram"] != "2048" {
+		t.Errorf("CI record: want ram 2048, got %q", record["ram"])
+	}
+
+	// The ProviderConfig should be tracked as used by the CI, and the
+	// connection details of the CI should be published.
+	eventually(t, "ProviderConfig usage to be tracked", func() (bool, error) {
+		l := &apisv1alpha1.ProviderConfigUsageList{}
+		if err := kube.List(ctx, l); err != nil {
+			return false, err
+		}
+		for _, u := range l.Items {
+			if u.ResourceReference.Name == cr.GetName() && u.ProviderConfigReference.Name == pc.GetName() {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	eventually(t, "connection secret to be published", func() (bool, error) {
+		err := kube.Get(ctx, types.NamespacedName{Namespace: testNamespace, Name: "web-1-connection"}, &corev1.Secret{})
+		return err == nil, client.IgnoreNotFound(err)
+	})
+
+	// A change made in ServiceNow should be reverted.
+	sn.Update(sysID, servicenow.Record{"ram": "1024"})
+	eventually(t, "drift to be corrected", func() (bool, error) {
+		r, _ := sn.Get(sysID)
+		return r["ram"] == "2048", nil
+	})
+
+	// A deleted CI should be released by the controller. CIs are never
+	// deleted from ServiceNow, so the CI must orphan its record.
+	got := &v1alpha1.CI{}
+	if err := kube.Get(ctx, types.NamespacedName{Name: cr.GetName()}, got); err != nil {
+		t.Fatalf("kube.Get(...): %v", err)
+	}
+	got.SetDeletionPolicy(xpv1.DeletionOrphan)
+	if err := kube.Update(ctx, got); err != nil {
+		t.Fatalf("kube.Update(...): %v", err)
+	}
+	if err := kube.Delete(ctx, got); err != nil {
+		t.Fatalf("kube.Delete(...): %v", err)
+	}
+	eventually(t, "CI to be deleted", func() (bool, error) {
+		err := kube.Get(ctx, types.NamespacedName{Name: cr.GetName()}, &v1alpha1.CI{})
+		return kerrors.IsNotFound(err), client.IgnoreNotFound(err)
+	})
+	if _, ok := sn.Get(sysID); !ok {
+		t.Errorf("CI record %q was deleted from ServiceNow", sysID)
+	}
+}
+
+func mustCreate(ctx context.Context, t *testing.T, kube client.Client, o client.Object) {
+	t.Helper()
+	if err := kube.Create(ctx, o); err != nil {
+		t.Fatalf("kube.Create(%s): %v", o.GetName(), err)
+	}
+}
+
+func eventually(t *testing.T, what string, fn wait.ConditionFunc) {
+	t.Helper()
+	if err := wait.PollImmediate(envtestInterval, envtestTimeout, fn); err != nil {
+		t.Fatalf("waiting for %s: %v", what, err)
+	}
+}
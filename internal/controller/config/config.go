@@ -27,10 +27,11 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
 	"github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
 )
 
 // Setup adds a controller that reconciles ProviderConfigs by accounting for
-// their current usage.
+// their current usage and periodically probing the instance they point to.
 func Setup(mgr ctrl.Manager, o controller.Options) error {
 	name := providerconfig.ControllerName(v1alpha1.ProviderConfigGroupKind)
 
@@ -39,9 +40,19 @@ func Setup(mgr ctrl.Manager, o controller.Options) error {
 		UsageList: v1alpha1.ProviderConfigUsageListGroupVersionKind,
 	}
 
-	r := providerconfig.NewReconciler(mgr, of,
-		providerconfig.WithLogger(o.Logger.WithValues("controller", name)),
-		providerconfig.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name))))
+	log := o.Logger.WithValues("controller", name)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+
+	r := &healthReconciler{
+		kube: mgr.GetClient(),
+		wrapped: providerconfig.NewReconciler(mgr, of,
+			providerconfig.WithLogger(log),
+			providerconfig.WithRecorder(recorder)),
+		newServiceFn: table.NewTableClient,
+		interval:     o.PollInterval,
+		log:          log,
+		record:       recorder,
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
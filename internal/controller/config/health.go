@@ -0,0 +1,208 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	sdkTable "github.com/anka-software/cmdb-sdk/pkg/client/table"
+
+	"github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/schedule"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+)
+
+const (
+	probeTimeout = 30 * time.Second
+
+	errGetPC           = "cannot get ProviderConfig"
+	errUpdateStatus    = "cannot update ProviderConfig status"
+	errProbeFailed     = "cannot reach ServiceNow instance"
+	errUnauthenticated = "ServiceNow rejected the configured username and password"
+	errForbidden       = "ServiceNow user is not allowed to use the Table API"
+)
+
+// Event reasons.
+const (
+	reasonProbe event.Reason = "ConnectivityProbe"
+)
+
+// A healthReconciler reconciles a ProviderConfig using a wrapped reconciler,
+// then probes the ServiceNow instance the ProviderConfig points to and
+// reflects the outcome as its Ready condition.
+type healthReconciler struct {
+	kube         client.Client
+	wrapped      reconcile.Reconciler
+	newServiceFn func(cfg clients.Config) sdkTable.ClientService
+	interval     time.Duration
+
+	log    logging.Logger
+	record event.Recorder
+
+	mu     sync.Mutex
+	probed map[string]probe
+}
+
+// A probe records when the instance of a version of a ProviderConfig was
+// last probed.
+type probe struct {
+	version string
+	at      time.Time
+}
+
+// due returns how long until the instance of the supplied config should be
+// probed again. ProviderConfigs are reconciled whenever a managed resource
+// starts or stops using them, so the instance is probed at most once per
+// interval for each version of a ProviderConfig.
+func (r *healthReconciler) due(cfg *clients.Config, now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.probed[cfg.ProviderConfigName]
+	if !ok || p.version != cfg.Version {
+		return 0
+	}
+	if d := p.at.Add(r.interval).Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+func (r *healthReconciler) probedAt(cfg *clients.Config, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.probed == nil {
+		r.probed = map[string]probe{}
+	}
+	r.probed[cfg.ProviderConfigName] = probe{version: cfg.Version, at: at}
+}
+
+func (r *healthReconciler) forget(providerConfigName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.probed, providerConfigName)
+	clients.ForgetProviderConfig(providerConfigName)
+	schedule.ForgetProviderConfig(providerConfigName)
+}
+
+// Reconcile a ProviderConfig and probe its ServiceNow instance.
+func (r *healthReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	result, err := r.wrapped.Reconcile(ctx, req)
+	if err != nil {
+		return result, err
+	}
+
+	log := r.log.WithValues("request", req)
+
+	pc := &v1alpha1.ProviderConfig{}
+	if err := r.kube.Get(ctx, req.NamespacedName, pc); err != nil {
+		log.Debug(errGetPC, "error", err)
+		if kerrors.IsNotFound(err) {
+			r.forget(req.Name)
+		}
+		return result, errors.Wrap(resource.IgnoreNotFound(err), errGetPC)
+	}
+
+	if meta.WasDeleted(pc) {
+		r.forget(pc.GetName())
+		return result, nil
+	}
+
+	if result.RequeueAfter == 0 || result.RequeueAfter > r.interval {
+		result.RequeueAfter = r.interval
+	}
+
+	status := pc.Status.DeepCopy()
+	now := time.Now()
+	cfg, err := clients.ConfigFromProviderConfig(ctx, r.kube, pc)
+	if err == nil {
+		if d := r.due(cfg, now); d > 0 {
+			log.Debug("Skipping connectivity probe, the instance was probed recently")
+			if d < result.RequeueAfter {
+				result.RequeueAfter = d
+			}
+			return result, nil
+		}
+	}
+	var info table.InstanceInfo
+	if err == nil {
+		info, err = r.probe(ctx, cfg)
+	}
+	if err != nil {
+		log.Debug(errProbeFailed, "error", err)
+		if e, ok := clients.AsUnavailable(err); ok {
+			pc.SetConditions(e.Condition())
+		} else {
+			pc.SetConditions(xpv1.Unavailable().WithMessage(err.Error()))
+		}
+		// A failing probe is only reported once, rather than on every poll.
+		if !pc.GetCondition(xpv1.TypeReady).Equal(status.GetCondition(xpv1.TypeReady)) {
+			r.record.Event(pc, event.Warning(reasonProbe, err))
+		}
+	} else {
+		pc.Status.Instance = v1alpha1.InstanceObservation{Version: info.Version, Build: info.Build}
+		pc.SetConditions(xpv1.Available())
+	}
+
+	// Conditions keep their transition time unless they change, so an
+	// unchanged status does not trigger another reconcile.
+	if !reflect.DeepEqual(status, &pc.Status) {
+		if err := r.kube.Status().Update(ctx, pc); err != nil {
+			return result, errors.Wrap(err, errUpdateStatus)
+		}
+	}
+	// The probe is only recorded once its outcome is, so that a failed
+	// status update is retried with another probe.
+	if cfg != nil {
+		r.probedAt(cfg, now)
+	}
+
+	return result, nil
+}
+
+// probe authenticates to the instance and reads its release information.
+func (r *healthReconciler) probe(ctx context.Context, cfg *clients.Config) (table.InstanceInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	response, err := r.newServiceFn(*cfg).GetTableItems(table.GenerateGetInstanceInfoOptions(ctx), table.WithReadOptions(table.ReadOptions{Fields: []string{"name", "value"}}))
+	switch err.(type) {
+	case nil:
+	case *sdkTable.GetTableItemsUnauthorized:
+		return table.InstanceInfo{}, errors.New(errUnauthenticated)
+	case *sdkTable.GetTableItemsForbidden:
+		return table.InstanceInfo{}, errors.New(errForbidden)
+	default:
+		return table.InstanceInfo{}, errors.Wrap(err, errProbeFailed)
+	}
+
+	return table.GetInstanceInfo(response.Payload.Result), nil
+}
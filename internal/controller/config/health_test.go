@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	sdkTable "github.com/anka-software/cmdb-sdk/pkg/client/table"
+	"github.com/anka-software/cmdb-sdk/pkg/models"
+
+	"github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/fake"
+)
+
+// A recorder keeps the events it records.
+type recorder struct {
+	events []event.Event
+}
+
+func (r *recorder) Event(_ runtime.Object, e event.Event) {
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) WithAnnotations(_ ...string) event.Recorder {
+	return r
+}
+
+func providerConfig(c ...xpv1.Condition) *v1alpha1.ProviderConfig {
+	pc := &v1alpha1.ProviderConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: v1alpha1.ProviderConfigSpec{
+			BaseURL:  "https://example.service-now.com",
+			Username: "admin",
+			Credentials: v1alpha1.ProviderCredentials{
+				Source: xpv1.CredentialsSourceSecret,
+				CommonCredentialSelectors: xpv1.CommonCredentialSelectors{
+					SecretRef: &xpv1.SecretKeySelector{SecretReference: xpv1.SecretReference{Namespace: "crossplane-system", Name: "cmdb"}, Key: "password"},
+				},
+			},
+		},
+	}
+	pc.SetConditions(c...)
+	return pc
+}
+
+func TestHealthReconcile(t *testing.T) {
+	errBoom := errors.New("boom")
+	unreachable := xpv1.Unavailable().WithMessage(errors.Wrap(errBoom, errProbeFailed).Error())
+
+	type want struct {
+		result   reconcile.Result
+		err      error
+		ready    xpv1.Condition
+		instance v1alpha1.InstanceObservation
+		events   int
+	}
+
+	cases := map[string]struct {
+		reason string
+		pc     *v1alpha1.ProviderConfig
+		get    func(*sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error)
+		want   want
+	}{
+		"Healthy": {
+			reason: "A reachable instance should make the ProviderConfig available and report its release.",
+			pc:     providerConfig(),
+			get: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+				return &sdkTable.GetTableItemsOK{Payload: &models.GetTableItem{Result: []map[string]interface{}{
+					{"name": "glide.buildname", "value": "Tokyo"},
+					{"name": "glide.buildtag", "value": "glide-tokyo"},
+				}}}, nil
+			},
+			want: want{
+				result:   reconcile.Result{RequeueAfter: time.Minute},
+				ready:    xpv1.Available(),
+				instance: v1alpha1.InstanceObservation{Version: "Tokyo", Build: "glide-tokyo"},
+			},
+		},
+		"Unauthorized": {
+			reason: "Rejected credentials should make the ProviderConfig unavailable and be reported once.",
+			pc:     providerConfig(),
+			get: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+				return nil, &sdkTable.GetTableItemsUnauthorized{}
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: time.Minute},
+				ready:  xpv1.Unavailable().WithMessage(errUnauthenticated),
+				events: 1,
+			},
+		},
+		"Unreachable": {
+			reason: "An unreachable instance should make the ProviderConfig unavailable and be reported once.",
+			pc:     providerConfig(),
+			get: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+				return nil, errBoom
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: time.Minute},
+				ready:  unreachable,
+				events: 1,
+			},
+		},
+		"StillUnreachable": {
+			reason: "A probe that keeps failing the same way should not be reported again.",
+			pc:     providerConfig(unreachable),
+			get: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+				return nil, errBoom
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: time.Minute},
+				ready:  unreachable,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			pc := tc.pc
+			kube := &test.MockClient{
+				MockGet: func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+					switch o := obj.(type) {
+					case *v1alpha1.ProviderConfig:
+						tc.pc.DeepCopyInto(o)
+					case *corev1.Secret:
+						o.Data = map[string][]byte{"password": []byte("password")}
+					}
+					return nil
+				},
+				MockStatusUpdate: func(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+					pc = obj.(*v1alpha1.ProviderConfig)
+					return nil
+				},
+			}
+			rec := &recorder{}
+			r := &healthReconciler{
+				kube:    kube,
+				wrapped: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) { return reconcile.Result{}, nil }),
+				newServiceFn: func(_ clients.Config) sdkTable.ClientService {
+					return &fake.MockTableClient{MockGetTableItems: tc.get}
+				},
+				interval: time.Minute,
+				log:      logging.NewNopLogger(),
+				record:   rec,
+			}
+
+			got, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: tc.pc.GetName()}})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.result, got); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ready, pc.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want ready condition, +got:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.instance, pc.Status.Instance); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want instance, +got:\n%s\n", tc.reason, diff)
+			}
+			if len(rec.events) != tc.want.events {
+				t.Errorf("\n%s\nr.Reconcile(...): want %d events, got %d", tc.reason, tc.want.events, len(rec.events))
+			}
+		})
+	}
+}
+
+func TestHealthReconcileProbesOncePerInterval(t *testing.T) {
+	pc := providerConfig()
+	kube := &test.MockClient{
+		MockGet: func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+			switch o := obj.(type) {
+			case *v1alpha1.ProviderConfig:
+				pc.DeepCopyInto(o)
+			case *corev1.Secret:
+				o.Data = map[string][]byte{"password": []byte("password")}
+			}
+			return nil
+		},
+		MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+	}
+	probes := 0
+	r := &healthReconciler{
+		kube:    kube,
+		wrapped: reconcile.Func(func(_ context.Context, _ reconcile.Request) (reconcile.Result, error) { return reconcile.Result{}, nil }),
+		newServiceFn: func(_ clients.Config) sdkTable.ClientService {
+			return &fake.MockTableClient{MockGetTableItems: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+				probes++
+				return &sdkTable.GetTableItemsOK{Payload: &models.GetTableItem{}}, nil
+			}}
+		},
+		interval: time.Minute,
+		log:      logging.NewNopLogger(),
+		record:   &recorder{},
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: pc.GetName()}}
+
+	for i := 0; i < 3; i++ {
+		got, err := r.Reconcile(context.Background(), req)
+		if err != nil {
+			t.Fatalf("r.Reconcile(...): %v", err)
+		}
+		if got.RequeueAfter <= 0 || got.RequeueAfter > time.Minute {
+			t.Errorf("r.Reconcile(...): want a requeue within the interval, got %v", got.RequeueAfter)
+		}
+	}
+	if probes != 1 {
+		t.Errorf("r.Reconcile(...): want the instance to be probed once per interval, got %d probes", probes)
+	}
+
+	pc.SetGeneration(2)
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("r.Reconcile(...): %v", err)
+	}
+	if probes != 2 {
+		t.Errorf("r.Reconcile(...): want a changed ProviderConfig to be probed again, got %d probes", probes)
+	}
+}
@@ -24,4 +24,8 @@ const (
 	// External Secret Stores. See the below design for more details.
 	// https://github.com/crossplane/crossplane/blob/390ddd/design/design-doc-external-secret-stores.md
 	EnableAlphaExternalSecretStores feature.Flag = "EnableAlphaExternalSecretStores"
+
+	// DryRun prevents any change to ServiceNow. Controllers observe their
+	// resources and report the changes they would make instead.
+	DryRun feature.Flag = "DryRun"
 )
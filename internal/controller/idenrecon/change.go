@@ -0,0 +1,188 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package idenrecon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+	"github.com/crossplane/provider-cmdb/internal/tracing"
+)
+
+const (
+	errRequestChange = "cannot create change request with Table API"
+	errGetChange     = "cannot get change request with Table API"
+	errFindChange    = "cannot query change requests with Table API"
+)
+
+// Table, fields and values of change requests.
+const (
+	tableChangeRequest = "change_request"
+
+	fieldNumber           = "number"
+	fieldSysID            = "sys_id"
+	fieldState            = "state"
+	fieldApproval         = "approval"
+	fieldShortDescription = "short_description"
+	fieldDescription      = "description"
+	fieldCmdbCI           = "cmdb_ci"
+	fieldActive           = "active"
+	fieldCreatedOn        = "sys_created_on"
+
+	approvalRejected = "rejected"
+	stateCanceled    = "4"
+)
+
+// defaultApprovedStates are the Scheduled and Implement states of a change
+// request.
+var defaultApprovedStates = []string{"-2", "-1"}
+
+// changeControl returns the change control of the supplied CI, which
+// overrides that of its ProviderConfig.
+func changeControl(cr *v1alpha1.CI, cfg clients.Config) *apisv1alpha1.ChangeControl {
+	if cc := cr.Spec.ForProvider.ChangeControl; cc != nil {
+		return cc
+	}
+	return cfg.ChangeControl
+}
+
+// approved returns true if an update of the supplied outdated fields of the CI
+// was approved by a change request. The change is requested if it was not
+// already, or if other fields were outdated when it was.
+func (c *external) approved(ctx context.Context, cr *v1alpha1.CI, cc *apisv1alpha1.ChangeControl, fields []string) (bool, error) {
+	cur := cr.Status.AtProvider.ChangeRequest
+	if cur == nil || strings.Join(cur.Fields, ",") != strings.Join(fields, ",") {
+		return false, c.requestChange(ctx, cr, cc, fields)
+	}
+
+	spanCtx, span := tracing.Start(ctx, "ServiceNow Table GetTableItems", attribute.String("servicenow.table", tableChangeRequest))
+	response, err := c.serviceTable.GetTableItems(table.GenerateGetRecordOptions(spanCtx, tableChangeRequest, cur.SysID),
+		table.WithReadOptions(table.ReadOptions{Fields: []string{fieldState, fieldApproval}, Limit: 1}))
+	tracing.End(span, err)
+	if err != nil && !clients.IsNotFound(err) {
+		return false, errors.Wrap(clients.Annotate(err), errGetChange)
+	}
+	if err != nil || len(response.Payload.Result) == 0 {
+		// The change request was deleted in ServiceNow.
+		return false, c.requestChange(ctx, cr, cc, fields)
+	}
+
+	state, _ := response.Payload.Result[0][fieldState].(string)
+	approval, _ := response.Payload.Result[0][fieldApproval].(string)
+	changed := cur.State != state || cur.Approval != approval
+	cur.State, cur.Approval = state, approval
+
+	states := cc.ApprovedStates
+	if len(states) == 0 {
+		states = defaultApprovedStates
+	}
+	for _, s := range states {
+		if state == s {
+			cr.SetConditions(v1alpha1.ChangeApproved(cur.Number))
+			return true, nil
+		}
+	}
+
+	if approval == approvalRejected || state == stateCanceled {
+		if changed {
+			c.log.Info("Change request was rejected", "number", cur.Number, "state", state, "approval", approval)
+			c.record.Event(cr, event.Warning(reasonRejectedChange, errors.Errorf("Change request %s was rejected or canceled, fields %s of the CI are not updated", cur.Number, strings.Join(fields, ", "))))
+		}
+		cr.SetConditions(v1alpha1.ChangeRejected(cur.Number))
+		return false, nil
+	}
+
+	cr.SetConditions(v1alpha1.AwaitingApproval(cur.Number))
+	return false, nil
+}
+
+// requestChange creates a change request to update the supplied fields of the
+// CI, and records it in the status of the CI. An active change request of the
+// CI is adopted instead, since it was likely created by a reconcile whose
+// status was not saved.
+func (c *external) requestChange(ctx context.Context, cr *v1alpha1.CI, cc *apisv1alpha1.ChangeControl, fields []string) error {
+	rec, err := c.activeChange(ctx, cr)
+	if err != nil {
+		return err
+	}
+	if rec != nil {
+		number, _ := rec[fieldNumber].(string)
+		c.log.Info("Adopted active change request", "number", number, "fields", fields)
+		setChangeRequest(cr, rec, fields)
+		return nil
+	}
+
+	values := make(map[string]string, len(cc.Values)+3)
+	for k, v := range cc.Values {
+		values[k] = v
+	}
+	p := &cr.Spec.ForProvider
+	values[fieldShortDescription] = fmt.Sprintf("Update CI %s", p.Name)
+	values[fieldDescription] = fmt.Sprintf("Update fields %s of %s CI %s, managed by %s.", strings.Join(fields, ", "), p.ClassName, p.Name, cr.GetName())
+	values[fieldCmdbCI] = meta.GetExternalName(cr)
+
+	spanCtx, span := tracing.Start(ctx, "ServiceNow Table InsertRecord", attribute.String("servicenow.table", tableChangeRequest))
+	response, err := c.serviceTable.GetTableItems(table.GenerateInsertRecordOptions(spanCtx, tableChangeRequest), table.WithRecord(values))
+	tracing.End(span, err)
+	if err != nil {
+		return errors.Wrap(clients.Annotate(err), errRequestChange)
+	}
+
+	if len(response.Payload.Result) > 0 {
+		rec = response.Payload.Result[0]
+	}
+	number, _ := rec[fieldNumber].(string)
+
+	c.log.Info("Requested change", "number", number, "fields", fields)
+	c.record.Event(cr, event.Normal(reasonRequestedChange, fmt.Sprintf("Requested change %s to update fields %s of the CI", number, strings.Join(fields, ", "))))
+	setChangeRequest(cr, rec, fields)
+	return nil
+}
+
+// activeChange returns the latest active change request of the supplied CI,
+// other than the one recorded in its status, or nil if it has none.
+func (c *external) activeChange(ctx context.Context, cr *v1alpha1.CI) (map[string]interface{}, error) {
+	q := table.NewQuery(table.Equals(fieldCmdbCI, meta.GetExternalName(cr)), table.Equals(fieldActive, "true"))
+	if cur := cr.Status.AtProvider.ChangeRequest; cur != nil && cur.SysID != "" {
+		q.And(table.Compare(fieldSysID, table.OpNotEquals, cur.SysID))
+	}
+	q.OrderByDesc(fieldCreatedOn)
+
+	spanCtx, span := tracing.Start(ctx, "ServiceNow Table GetTableItems", attribute.String("servicenow.table", tableChangeRequest))
+	response, err := c.serviceTable.GetTableItems(table.GenerateQueryOptions(spanCtx, tableChangeRequest, q.String()),
+		table.WithReadOptions(table.ReadOptions{Fields: []string{fieldNumber, fieldSysID, fieldState, fieldApproval}, Limit: 1}))
+	tracing.End(span, err)
+	if err != nil {
+		return nil, errors.Wrap(clients.Annotate(err), errFindChange)
+	}
+	if response.Payload == nil || len(response.Payload.Result) == 0 {
+		return nil, nil
+	}
+	return response.Payload.Result[0], nil
+}
+
+// setChangeRequest records the supplied change request to update the supplied
+// fields in the status of the CI.
+func setChangeRequest(cr *v1alpha1.CI, rec map[string]interface{}, fields []string) {
+	number, _ := rec[fieldNumber].(string)
+	sysID, _ := rec[fieldSysID].(string)
+	state, _ := rec[fieldState].(string)
+	approval, _ := rec[fieldApproval].(string)
+
+	cr.Status.AtProvider.ChangeRequest = &v1alpha1.ChangeRequest{Number: number, SysID: sysID, State: state, Approval: approval, Fields: fields, RequestedTime: metav1.Now()}
+	cr.SetConditions(v1alpha1.AwaitingApproval(number))
+}
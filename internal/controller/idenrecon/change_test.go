@@ -0,0 +1,138 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package idenrecon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
+	cmdbmeta "github.com/crossplane/provider-cmdb/internal/clients/meta"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+// TestChangeControl drifts a CI of a fake ServiceNow instance, and updates it
+// only once the change request for the update is approved.
+func TestChangeControl(t *testing.T) {
+	sn := servicenow.NewServer()
+	defer sn.Close()
+
+	ctx := context.Background()
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name(),
+		ChangeControl: &apisv1alpha1.ChangeControl{Values: map[string]string{"assignment_group": "cmdb-admins"}}}
+	newExternal := func() *external {
+		return &external{
+			serviceIdenRecon: idenrecon.NewIdenReconClient(cfg),
+			serviceTable:     table.NewTableClient(cfg),
+			serviceMeta:      cmdbmeta.NewMetaClient(cfg),
+			log:              logging.NewNopLogger(),
+			record:           event.NewNopRecorder(),
+			cfg:              cfg,
+		}
+	}
+
+	sysID := sn.Insert(testClass, servicenow.Record{"name": testName, "ram": "1024"})
+	cr := ci(withExternalName(sysID))
+
+	observe := func(want managed.ExternalObservation, reason xpv1.ConditionReason) {
+		t.Helper()
+		o, err := newExternal().Observe(ctx, cr)
+		if err != nil {
+			t.Fatalf("Observe(...): %v", err)
+		}
+		if diff := cmp.Diff(want, o); diff != "" {
+			t.Errorf("Observe(...): -want, +got:\n%s\n", diff)
+		}
+		if got := cr.GetCondition(v1alpha1.TypeChangeApproved).Reason; got != reason {
+			t.Errorf("Observe(...): want %s condition reason %q, got %q", v1alpha1.TypeChangeApproved, reason, got)
+		}
+	}
+
+	// The drift is not corrected until the change request is approved.
+	observe(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, v1alpha1.ReasonAwaitingApproval)
+	chg := cr.Status.AtProvider.ChangeRequest
+	if chg == nil {
+		t.Fatalf("Observe(...): want a change request in the status of the CI")
+	}
+	r, _ := sn.Get(chg.SysID)
+	if diff := cmp.Diff(map[string]string{fieldNumber: chg.Number, fieldCmdbCI: sysID, "assignment_group": "cmdb-admins"},
+		map[string]string{fieldNumber: r[fieldNumber], fieldCmdbCI: r[fieldCmdbCI], "assignment_group": r["assignment_group"]}); diff != "" {
+		t.Errorf("Observe(...): -want change request, +got change request:\n%s\n", diff)
+	}
+
+	observe(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, v1alpha1.ReasonAwaitingApproval)
+	if got := len(sn.Records(servicenow.TableChangeRequest)); got != 1 {
+		t.Errorf("Observe(...): want the change to be requested once, got %d change requests", got)
+	}
+
+	// The active change request is adopted if the status that recorded it
+	// was not saved.
+	cr.Status.AtProvider.ChangeRequest = nil
+	observe(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, v1alpha1.ReasonAwaitingApproval)
+	if got := len(sn.Records(servicenow.TableChangeRequest)); got != 1 {
+		t.Errorf("Observe(...): want the active change request to be adopted, got %d change requests", got)
+	}
+	if got := cr.Status.AtProvider.ChangeRequest; got == nil || got.SysID != chg.SysID {
+		t.Fatalf("Observe(...): want change request %s in the status of the CI, got %v", chg.Number, got)
+	}
+	chg = cr.Status.AtProvider.ChangeRequest
+
+	sn.Update(chg.SysID, servicenow.Record{fieldApproval: approvalRejected})
+	observe(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, v1alpha1.ReasonChangeRejected)
+
+	sn.Update(chg.SysID, servicenow.Record{fieldApproval: "approved", fieldState: "-1"})
+	observe(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false}, v1alpha1.ReasonChangeApproved)
+
+	if _, err := newExternal().Update(ctx, cr); err != nil {
+		t.Fatalf("Update(...): %v", err)
+	}
+	if r, _ := sn.Get(sysID); r["ram"] != "2048" {
+		t.Errorf("Update(...): want the approved change to be made, got %v", r)
+	}
+	if cr.Status.AtProvider.ChangeRequest != nil {
+		t.Errorf("Update(...): want the implemented change request to be cleared from the status of the CI")
+	}
+	observe(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, v1alpha1.ReasonChangeApproved)
+}
+
+func TestChangeControlOverride(t *testing.T) {
+	cfg := clients.Config{ChangeControl: &apisv1alpha1.ChangeControl{}}
+
+	cases := map[string]struct {
+		reason string
+		cc     *apisv1alpha1.ChangeControl
+		want   bool
+	}{
+		"ProviderConfig": {
+			reason: "The change control of the ProviderConfig should apply to CIs without their own.",
+			want:   true,
+		},
+		"None": {
+			reason: "A CI should be able to opt out of the change control of its ProviderConfig.",
+			cc:     &apisv1alpha1.ChangeControl{Policy: apisv1alpha1.ChangeControlNone},
+			want:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cr := ci(func(cr *v1alpha1.CI) { cr.Spec.ForProvider.ChangeControl = tc.cc })
+			if got := changeControl(cr, cfg).Enabled(); got != tc.want {
+				t.Errorf("\n%s\nchangeControl(...).Enabled(): want %t, got %t", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
@@ -7,16 +7,22 @@ package idenrecon
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
+
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/connection"
 	"github.com/crossplane/crossplane-runtime/pkg/controller"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -24,21 +30,31 @@ import (
 
 	sdkMeta "github.com/anka-software/cmdb-sdk/pkg/client/cmdb_meta"
 	sdkTable "github.com/anka-software/cmdb-sdk/pkg/client/table"
+	"github.com/anka-software/cmdb-sdk/pkg/models"
 	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
 	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
 	"github.com/crossplane/provider-cmdb/internal/clients"
 	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
 	cmdbmeta "github.com/crossplane/provider-cmdb/internal/clients/meta"
+	"github.com/crossplane/provider-cmdb/internal/clients/schedule"
 	"github.com/crossplane/provider-cmdb/internal/clients/table"
 	"github.com/crossplane/provider-cmdb/internal/controller/features"
+	"github.com/crossplane/provider-cmdb/internal/controller/ratelimit"
+	"github.com/crossplane/provider-cmdb/internal/tracing"
 )
 
 const (
 	errNotCI        = "managed resource is not a CI custom resource"
 	errTrackPCUsage = "cannot track ProviderConfig usage"
 
-	errCreateFailed = "cannot create CI with Identification and Reconciliation API"
-	// errGetFailed    = "cannot get CI with Table API"
+	errCreateFailed  = "cannot create CI with Identification and Reconciliation API"
+	errGetFailed     = "cannot get CI with Table API"
+	errGetMetaFailed = "cannot get CI class metadata with CMDB Meta API"
+	errPlanFailed    = "cannot encode the planned CI payload"
+	errResolveValues = "cannot resolve CI values"
+	errSchedule      = "cannot evaluate the write window of the ProviderConfig"
+	errUnknownFields = "CI values are not fields of its class"
+	errShortResult   = "Identification and Reconciliation API returned no items"
 	// errDeleteFailed = "cannot delete CI with Table API"
 )
 
@@ -51,6 +67,12 @@ func Setup(mgr ctrl.Manager, o controller.Options) error {
 		cps = append(cps, connection.NewDetailsManager(mgr.GetClient(), apisv1alpha1.StoreConfigGroupVersionKind))
 	}
 
+	log := o.Logger.WithValues("controller", name)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	if err := registerCICollector(mgr.GetClient(), log); err != nil {
+		return err
+	}
+
 	r := managed.NewReconciler(mgr,
 		resource.ManagedKind(v1alpha1.CIGroupVersionKind),
 		managed.WithExternalConnecter(&connector{
@@ -59,16 +81,24 @@ func Setup(mgr ctrl.Manager, o controller.Options) error {
 			newServiceFnIdenRecon: idenrecon.NewIdenReconClient,
 			newServiceFnTable:     table.NewTableClient,
 			newServiceFnMeta:      cmdbmeta.NewMetaClient,
+			log:                   log,
+			record:                recorder,
+			dryRun:                o.Features.Enabled(features.DryRun),
 		}),
-		managed.WithLogger(o.Logger.WithValues("controller", name)),
-		managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name))),
+		managed.WithLogger(log),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithRecorder(recorder),
 		managed.WithConnectionPublishers(cps...))
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		WithOptions(o.ForControllerRuntime()).
-		For(&v1alpha1.CI{}).
-		Complete(ratelimiter.NewReconciler(name, r, o.GlobalRateLimiter))
+		For(&v1alpha1.CI{})
+	if err := setupValueWatches(mgr, b, log); err != nil {
+		return err
+	}
+
+	return b.Complete(ratelimiter.NewReconciler(name, tracing.NewReconciler(name, ratelimit.NewReconciler(mgr.GetClient(), resource.ManagedKind(v1alpha1.CIGroupVersionKind), r, log)), o.GlobalRateLimiter))
 }
 
 // A connector is expected to produce an ExternalClient when its Connect method
@@ -79,6 +109,9 @@ type connector struct {
 	newServiceFnIdenRecon func(cfg clients.Config) sdkIdenRecon.ClientService
 	newServiceFnTable     func(cfg clients.Config) sdkTable.ClientService
 	newServiceFnMeta      func(cfg clients.Config) sdkMeta.ClientService
+	log                   logging.Logger
+	record                event.Recorder
+	dryRun                bool
 }
 
 // Connect typically produces an ExternalClient by:
@@ -101,7 +134,15 @@ func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.E
 		return nil, err
 	}
 
-	return &external{kube: c.kube, serviceIdenRecon: c.newServiceFnIdenRecon(*cfg), serviceTable: c.newServiceFnTable(*cfg), serviceMeta: c.newServiceFnMeta(*cfg)}, nil
+	values, sensitive, err := resolveValues(ctx, c.kube, cr)
+	if err != nil {
+		return nil, errors.Wrap(err, errResolveValues)
+	}
+
+	log := c.log.WithValues("resource", cr.GetName(), "class", cr.Spec.ForProvider.ClassName)
+
+	serviceTable := c.newServiceFnTable(*cfg)
+	return &external{kube: c.kube, serviceIdenRecon: c.newServiceFnIdenRecon(*cfg), serviceTable: serviceTable, serviceMeta: c.newServiceFnMeta(*cfg), log: log, record: c.record, cfg: *cfg, readOnly: c.dryRun || cfg.ReadOnly, gate: schedule.NewGate(*cfg, serviceTable), defaults: metadataValues(cr, *cfg), values: values, sensitive: sensitive}, nil
 }
 
 // An ExternalClient observes, then either creates, updates, or deletes an
@@ -112,6 +153,30 @@ type external struct {
 	serviceIdenRecon sdkIdenRecon.ClientService
 	serviceTable     sdkTable.ClientService
 	serviceMeta      sdkMeta.ClientService
+	log              logging.Logger
+	record           event.Recorder
+	cfg              clients.Config
+
+	// readOnly clients plan the changes they would make to ServiceNow in
+	// Observe, and report the CI as up to date so that they are not made.
+	readOnly bool
+
+	// gate defers the creation and update of CIs outside the write windows
+	// of the ProviderConfig.
+	gate *schedule.Gate
+
+	// defaults are values read from the labels and annotations of the CI,
+	// which its own values take precedence over.
+	defaults map[string]string
+
+	// values read from Secrets and ConfigMaps, of which the sensitive fields
+	// are read from Secrets and must not be logged.
+	values    map[string]string
+	sensitive []string
+
+	// outdated are the fields found to differ from their desired values by
+	// Observe, and corrected by a subsequent Update.
+	outdated []string
 }
 
 func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -120,54 +185,111 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.New(errNotCI)
 	}
 
-	// These fmt statements should be removed in the real implementation.
-	fmt.Printf("Observing: \n%+v", cr)
+	if !c.readOnly {
+		cr.Status.AtProvider.PlannedChange = nil
+	}
+	ctx = clients.WithRedactedFields(ctx, c.sensitive...)
 
 	externalName := meta.GetExternalName(cr)
 	if externalName == "" {
-		return managed.ExternalObservation{ResourceExists: false}, nil
+		c.log.Debug("CI has no sys_id yet")
+		return c.observeMissing(ctx, cr)
 	}
+	log := c.log.WithValues("sysId", externalName)
 
 	forProvider := &cr.Spec.ForProvider
-	params := table.GenerateGetTableItemsOptions(forProvider.ClassName, forProvider.Name)
-
-	/*if forProvider.Name == ""{
-		return managed.ExternalObservation{ResourceExists: false}, nil
-	}*/
-
-	desired := cr.Spec.ForProvider.DeepCopy()
-
-	response, err := c.serviceTable.GetTableItems(params)
-
+	desired := c.parameters(cr)
+
+	start := time.Now()
+	spanCtx, span := tracing.Start(ctx, "ServiceNow Table GetTableItems", attribute.String("servicenow.table", forProvider.ClassName))
+	response, err := c.serviceTable.GetTableItems(table.GenerateGetTableItemsOptions(spanCtx, forProvider.ClassName, forProvider.Name),
+		table.WithReadOptions(table.ReadOptions{Fields: idenrecon.GetFieldNames(desired.Values), Limit: 1}))
+	tracing.End(span, err)
+	log.Debug("Queried CI with Table API", "duration", time.Since(start))
+	if clients.IsNotFound(err) {
+		return c.observeMissing(ctx, cr)
+	}
+	// Any other error, transient or not, must not be mistaken for a missing
+	// CI, which would make the reconciler create it again.
+	if e, ok := clients.AsUnavailable(err); ok {
+		cr.SetConditions(e.Condition())
+	}
 	if err != nil {
-		return managed.ExternalObservation{ResourceExists: false}, nil
+		return managed.ExternalObservation{}, errors.Wrap(clients.Annotate(err), errGetFailed)
 	}
 
 	if len(response.Payload.Result) == 0 {
-		return managed.ExternalObservation{ResourceExists: false}, nil
+		log.Debug("CI does not exist")
+		return c.observeMissing(ctx, cr)
 	}
-	metaParams := cmdbmeta.GenerateGetMetaOptions(desired.ClassName)
-	responseMeta, err := c.serviceMeta.GetCmdbMetaByClassName(metaParams)
+	spanCtx, span = tracing.Start(ctx, "ServiceNow CMDB Meta GetCmdbMetaByClassName", attribute.String("servicenow.class", desired.ClassName))
+	responseMeta, err := c.serviceMeta.GetCmdbMetaByClassName(cmdbmeta.GenerateGetMetaOptions(spanCtx, desired.ClassName))
+	tracing.End(span, err)
 	if err != nil {
-		return managed.ExternalObservation{}, errors.New(err.Error())
-
+		return managed.ExternalObservation{}, errors.Wrap(clients.Annotate(err), errGetMetaFailed)
 	}
 
 	var elementNames []string
 	for _, v := range responseMeta.Payload.Result.Attributes {
-		elementNames = append(elementNames, v.Element) // # CHANGED
-		//elementNames[i] = v.Element
+		elementNames = append(elementNames, v.Element)
 	}
 
 	err = idenrecon.ContainsField(elementNames, desired.Values)
 	if err != nil {
-		return managed.ExternalObservation{}, errors.New(err.Error())
+		c.record.Event(cr, event.Warning(reasonRejectedValues, err))
+		return managed.ExternalObservation{}, errors.Wrap(err, errUnknownFields)
 	}
 
 	currentResource := response.Payload.Result[0]
 
-	resourceUpToDate := idenrecon.IsResourceUpToDate(desired.Values, currentResource)
-	// currentResource
+	outdated := idenrecon.GetOutdatedFields(desired.Values, currentResource)
+	if len(outdated) > 0 {
+		log.Debug("CI is not up to date", "fields", outdated)
+	}
+	c.outdated = outdated
+
+	ready := xpv1.Available()
+	switch cc := changeControl(cr, c.cfg); {
+	case len(outdated) == 0:
+		cr.Status.AtProvider.PlannedChange = nil
+		cr.Status.AtProvider.ChangeRequest = nil
+	case c.readOnly:
+		if err := c.plan(ctx, cr, plannedUpdate, outdated); err != nil {
+			return managed.ExternalObservation{}, err
+		}
+		outdated = nil
+		ready = xpv1.Unavailable().WithMessage(msgPlannedUpdate)
+	default:
+		// The write window is checked first, so that no change request is
+		// opened for an update that is deferred.
+		open, err := c.gate.Open(ctx, cr)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errSchedule)
+		}
+		if !open {
+			log.Debug("Deferring update of CI until the write window opens", "fields", outdated)
+			outdated = nil
+			ready = xpv1.Unavailable().WithMessage(msgDeferredUpdate)
+			break
+		}
+		if !cc.Enabled() {
+			break
+		}
+		ok, err := c.approved(ctx, cr, cc, outdated)
+		if err != nil {
+			return managed.ExternalObservation{}, err
+		}
+		if !ok {
+			outdated = nil
+			ready = xpv1.Unavailable().WithMessage(msgPendingChange)
+		}
+	}
+
+	// A CI that is about to be updated keeps its condition until Update
+	// reports the outcome.
+	if len(outdated) == 0 {
+		cr.SetConditions(ready)
+	}
 
 	return managed.ExternalObservation{
 		// Return false when the external resource does not exist. This lets
@@ -178,29 +300,101 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		// Return false when the external resource exists, but it not up to date
 		// with the desired managed resource state. This lets the managed
 		// resource reconciler know that it needs to call Update.
-		ResourceUpToDate: resourceUpToDate,
+		ResourceUpToDate: len(outdated) == 0,
 	}, nil
 }
 
+// parameters returns the parameters of the supplied CI, with the values read
+// from its labels and annotations, and from Secrets and ConfigMaps.
+func (c *external) parameters(cr *v1alpha1.CI) *v1alpha1.CIParameters {
+	p := cr.Spec.ForProvider.DeepCopy()
+	if len(c.defaults) == 0 && len(c.values) == 0 {
+		return p
+	}
+	values := make(map[string]string, len(c.defaults)+len(p.Values)+len(c.values))
+	for _, m := range []map[string]string{c.defaults, p.Values, c.values} {
+		for k, v := range m {
+			values[k] = v
+		}
+	}
+	p.Values = values
+	return p
+}
+
+// observeMissing reports that the CI does not exist, unless the client is
+// read only, in which case its creation is planned instead, or the write
+// window of the ProviderConfig is closed, in which case it is deferred.
+func (c *external) observeMissing(ctx context.Context, cr *v1alpha1.CI) (managed.ExternalObservation, error) {
+	if meta.WasDeleted(cr) {
+		return managed.ExternalObservation{ResourceExists: false}, nil
+	}
+	if !c.readOnly {
+		open, err := c.gate.Open(ctx, cr)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errSchedule)
+		}
+		if open {
+			return managed.ExternalObservation{ResourceExists: false}, nil
+		}
+		c.log.Debug("Deferring creation of CI until the write window opens")
+		cr.SetConditions(xpv1.Unavailable().WithMessage(msgDeferredCreate))
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil
+	}
+	if err := c.plan(ctx, cr, plannedCreate, nil); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	cr.SetConditions(xpv1.Unavailable().WithMessage(msgPlannedCreate))
+	return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil
+}
+
+// plan records the supplied change in the status of the CI. It is logged
+// and recorded as an event only if it differs from the change already
+// planned, so that it is not reported on every poll.
+func (c *external) plan(ctx context.Context, cr *v1alpha1.CI, operation string, fields []string) error {
+	payload, err := clients.RedactedJSON(ctx, c.cfg, idenrecon.GenerateCIOptions(ctx, c.parameters(cr)).Body)
+	if err != nil {
+		return errors.Wrap(err, errPlanFailed)
+	}
+
+	p := cr.Status.AtProvider.PlannedChange
+	if p != nil && p.Operation == operation && p.Payload == payload && strings.Join(p.Fields, ",") == strings.Join(fields, ",") {
+		return nil
+	}
+
+	c.log.Info("Not sending CI to ServiceNow in dry run mode", "operation", operation, "fields", fields, "payload", payload)
+	recordPlan(c.record, cr, operation, fields, payload)
+	cr.Status.AtProvider.PlannedChange = &v1alpha1.PlannedChange{Operation: operation, Fields: fields, Payload: payload, PlannedTime: metav1.Now()}
+	return nil
+}
+
 func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
 	cr, ok := mg.(*v1alpha1.CI)
 	if !ok {
 		return managed.ExternalCreation{}, errors.New(errNotCI)
 	}
 
-	fmt.Printf("Creating: \n%+v", cr)
-
 	cr.Status.SetConditions(xpv1.Creating())
 
-	ciParams := idenrecon.GenerateCIOptions(&cr.Spec.ForProvider)
+	ctx = clients.WithRedactedFields(ctx, c.sensitive...)
+	p := c.parameters(cr)
+	c.log.Debug("Creating CI", "fields", idenrecon.GetFieldNames(p.Values))
 
-	response, err := c.serviceIdenRecon.CreateIdentifyReconcile(ciParams)
+	start := time.Now()
+	spanCtx, span := tracing.Start(ctx, "ServiceNow IRE CreateIdentifyReconcile", attribute.String("servicenow.class", cr.Spec.ForProvider.ClassName))
+	response, err := c.serviceIdenRecon.CreateIdentifyReconcile(idenrecon.GenerateCIOptions(spanCtx, p))
+	tracing.End(span, err)
 	if err != nil {
-		return managed.ExternalCreation{}, errors.Wrap(err, errCreateFailed)
+		recordOperation(cr, operationError)
+		return managed.ExternalCreation{}, errors.Wrap(clients.Annotate(err), errCreateFailed)
 	}
-
-	var item = (*response.Payload.Result.Items)[0]
-	fmt.Println("Sys ID : " + item.SysId)
+	item, err := firstItem(response)
+	if err != nil {
+		recordOperation(cr, operationError)
+		return managed.ExternalCreation{}, err
+	}
+	recordOperation(cr, item.Operation)
+	recordSubmission(c.record, cr, item.Operation, item.SysId)
+	c.log.Info("Created CI", "sysId", item.SysId, "operation", item.Operation, "duration", time.Since(start))
 
 	meta.SetExternalName(cr, item.SysId)
 
@@ -219,19 +413,36 @@ func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalUpdate{}, errors.New(errNotCI)
 	}
 
-	fmt.Printf("Updating: \n%+v", cr)
-
 	cr.Status.SetConditions(xpv1.Creating())
 
-	ciParams := idenrecon.GenerateCIOptions(&cr.Spec.ForProvider)
+	ctx = clients.WithRedactedFields(ctx, c.sensitive...)
+	p := c.parameters(cr)
+	c.log.Debug("Updating CI", "sysId", meta.GetExternalName(cr), "fields", idenrecon.GetFieldNames(p.Values))
 
-	response, err := c.serviceIdenRecon.CreateIdentifyReconcile(ciParams)
+	start := time.Now()
+	spanCtx, span := tracing.Start(ctx, "ServiceNow IRE CreateIdentifyReconcile", attribute.String("servicenow.class", cr.Spec.ForProvider.ClassName))
+	response, err := c.serviceIdenRecon.CreateIdentifyReconcile(idenrecon.GenerateCIOptions(spanCtx, p))
+	tracing.End(span, err)
+	if err != nil {
+		recordOperation(cr, operationError)
+		return managed.ExternalUpdate{}, errors.Wrap(clients.Annotate(err), errCreateFailed)
+	}
+	item, err := firstItem(response)
 	if err != nil {
-		return managed.ExternalUpdate{}, errors.Wrap(err, errCreateFailed)
+		recordOperation(cr, operationError)
+		return managed.ExternalUpdate{}, err
+	}
+	recordOperation(cr, item.Operation)
+	recordSubmission(c.record, cr, item.Operation, item.SysId)
+	if item.Operation != operationNoChange {
+		recordDrift(c.record, cr, item.SysId, c.outdated)
 	}
+	c.log.Info("Updated CI", "sysId", item.SysId, "operation", item.Operation, "duration", time.Since(start))
 
-	var item = (*response.Payload.Result.Items)[0]
-	fmt.Println("Sys ID : " + item.SysId)
+	if chg := cr.Status.AtProvider.ChangeRequest; chg != nil {
+		c.record.Event(cr, event.Normal(reasonImplementedChange, fmt.Sprintf("Updated CI %s as approved by change request %s", item.SysId, chg.Number)))
+		cr.Status.AtProvider.ChangeRequest = nil
+	}
 
 	// meta.SetExternalName(cr, item.SysId)
 
@@ -250,9 +461,22 @@ func (c *external) Delete(ctx context.Context, mg resource.Managed) error {
 		return errors.New(errNotCI)
 	}
 
-	fmt.Printf("Deleting: \n%+v", cr)
+	c.log.Debug("Deleting CI", "sysId", meta.GetExternalName(cr))
 
 	cr.Status.SetConditions(xpv1.Deleting())
 
+	// CIs are retired in ServiceNow by its own processes rather than deleted,
+	// so the record is left in place.
+	c.record.Event(cr, event.Normal(reasonReleasedCI, fmt.Sprintf("Released CI %s, the record is retained in ServiceNow", meta.GetExternalName(cr))))
+
 	return nil
 }
+
+// firstItem returns the result of the only item submitted to the
+// Identification and Reconciliation API.
+func firstItem(response *sdkIdenRecon.CreateIdentifyReconcileCreated) (models.Items, error) {
+	if response == nil || response.Payload == nil || response.Payload.Result == nil || response.Payload.Result.Items == nil || len(*response.Payload.Result.Items) == 0 {
+		return models.Items{}, errors.New(errShortResult)
+	}
+	return (*response.Payload.Result.Items)[0], nil
+}
@@ -0,0 +1,564 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package idenrecon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	resourcefake "github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	sdkIdenRecon "github.com/anka-software/cmdb-sdk/pkg/client/cmdb"
+	sdkMeta "github.com/anka-software/cmdb-sdk/pkg/client/cmdb_meta"
+	sdkTable "github.com/anka-software/cmdb-sdk/pkg/client/table"
+	"github.com/anka-software/cmdb-sdk/pkg/models"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/fake"
+	"github.com/crossplane/provider-cmdb/internal/clients/schedule"
+)
+
+// Unlike many Kubernetes projects Crossplane does not use third party testing
+// libraries, per the common Go test review comments. Crossplane encourages the
+// use of table driven unit tests. The tests of the crossplane-runtime project
+// are representative of the testing style Crossplane encourages.
+//
+// https://github.com/golang/go/wiki/TestComments
+// https://github.com/crossplane/crossplane/blob/master/CONTRIBUTING.md#contributing-code
+
+const (
+	testClass  = "cmdb_ci_linux_server"
+	testName   = "web-1"
+	testSysID  = "0123456789abcdef0123456789abcdef"
+	testSource = "ServiceNow"
+)
+
+var errBoom = errors.New("boom")
+
+type ciModifier func(*v1alpha1.CI)
+
+func withExternalName(n string) ciModifier {
+	return func(cr *v1alpha1.CI) { meta.SetExternalName(cr, n) }
+}
+
+func withValues(v map[string]string) ciModifier {
+	return func(cr *v1alpha1.CI) { cr.Spec.ForProvider.Values = v }
+}
+
+func ci(m ...ciModifier) *v1alpha1.CI {
+	cr := &v1alpha1.CI{
+		ObjectMeta: metav1.ObjectMeta{Name: testName},
+		Spec: v1alpha1.CISpec{
+			ForProvider: v1alpha1.CIParameters{
+				SysParamDataSource: testSource,
+				ClassName:          testClass,
+				Name:               testName,
+				Values:             map[string]string{"ram": "2048"},
+			},
+		},
+	}
+	for _, f := range m {
+		f(cr)
+	}
+	return cr
+}
+
+func tableItems(items ...map[string]interface{}) func(*sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+	return func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+		if items == nil {
+			items = []map[string]interface{}{}
+		}
+		return &sdkTable.GetTableItemsOK{Payload: &models.GetTableItem{Result: items}}, nil
+	}
+}
+
+func metaAttributes(elements ...string) func(*sdkMeta.GetCmdbMetaParams) (*sdkMeta.GetCmdbMetaOK, error) {
+	return func(_ *sdkMeta.GetCmdbMetaParams) (*sdkMeta.GetCmdbMetaOK, error) {
+		type attribute struct {
+			Element string `json:"element"`
+		}
+		attrs := make([]attribute, len(elements))
+		for i, e := range elements {
+			attrs[i] = attribute{Element: e}
+		}
+		b, _ := json.Marshal(map[string]interface{}{"result": map[string]interface{}{"name": testClass, "attributes": attrs}})
+		payload := &models.GetCMDClassSchema{}
+		if err := json.Unmarshal(b, payload); err != nil {
+			return nil, err
+		}
+		return &sdkMeta.GetCmdbMetaOK{Payload: payload}, nil
+	}
+}
+
+func ireResult(operation string) func(*sdkIdenRecon.CreateIdentifyReconcileParams) (*sdkIdenRecon.CreateIdentifyReconcileCreated, error) {
+	return ireItems(models.Items{ClassName: testClass, Operation: operation, SysId: testSysID})
+}
+
+func ireItems(items ...models.Items) func(*sdkIdenRecon.CreateIdentifyReconcileParams) (*sdkIdenRecon.CreateIdentifyReconcileCreated, error) {
+	return func(_ *sdkIdenRecon.CreateIdentifyReconcileParams) (*sdkIdenRecon.CreateIdentifyReconcileCreated, error) {
+		return &sdkIdenRecon.CreateIdentifyReconcileCreated{Payload: &models.IdentifyReconcileItem{Result: &models.Result{Items: &items}}}, nil
+	}
+}
+
+func TestObserve(t *testing.T) {
+	type fields struct {
+		table     sdkTable.ClientService
+		meta      sdkMeta.ClientService
+		readOnly  bool
+		gate      *schedule.Gate
+		values    map[string]string
+		sensitive []string
+	}
+
+	type args struct {
+		ctx context.Context
+		mg  resource.Managed
+	}
+
+	type want struct {
+		o       managed.ExternalObservation
+		planned *v1alpha1.PlannedChange
+		ready   *xpv1.Condition
+		err     error
+	}
+
+	available := xpv1.Available()
+	deferredUpdate := xpv1.Unavailable().WithMessage(msgDeferredUpdate)
+	planned := xpv1.Unavailable().WithMessage(msgPlannedUpdate)
+
+	errUnavailable := &runtime.APIError{OperationName: "getTableItems", Code: http.StatusServiceUnavailable}
+	closed := schedule.NewGate(clients.Config{Schedules: &apisv1alpha1.Schedules{
+		Deny: []apisv1alpha1.Window{{Start: "* * * * *", Duration: metav1.Duration{Duration: time.Hour}}},
+	}}, nil)
+
+	cases := map[string]struct {
+		reason string
+		fields fields
+		args   args
+		want   want
+	}{
+		"NotCI": {
+			reason: "An error should be returned if the managed resource is not a CI.",
+			args:   args{ctx: context.Background(), mg: &resourcefake.Managed{}},
+			want:   want{err: errors.New(errNotCI)},
+		},
+		"NoExternalName": {
+			reason: "A CI without an external name should not exist yet.",
+			args:   args{ctx: context.Background(), mg: ci()},
+			want:   want{o: managed.ExternalObservation{ResourceExists: false}},
+		},
+		"NotFound": {
+			reason: "A CI whose class table is not found should not exist.",
+			fields: fields{table: &fake.MockTableClient{
+				MockGetTableItems: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+					return nil, sdkTable.NewGetTableItemsNotFound()
+				},
+			}},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{o: managed.ExternalObservation{ResourceExists: false}},
+		},
+		"GetFailed": {
+			reason: "Errors querying the CI should be returned, marked as transient if they are.",
+			fields: fields{table: &fake.MockTableClient{
+				MockGetTableItems: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+					return nil, errUnavailable
+				},
+			}},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{err: errors.Wrap(clients.Annotate(errUnavailable), errGetFailed)},
+		},
+		"EmptyResult": {
+			reason: "A CI the Table API does not return should not exist.",
+			fields: fields{table: &fake.MockTableClient{MockGetTableItems: tableItems()}},
+			args:   args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want:   want{o: managed.ExternalObservation{ResourceExists: false}},
+		},
+		"GetMetaFailed": {
+			reason: "Errors reading the class metadata should be returned.",
+			fields: fields{
+				table: &fake.MockTableClient{MockGetTableItems: tableItems(map[string]interface{}{"name": testName, "ram": "2048"})},
+				meta: &fake.MockMetaClient{
+					MockGetCmdbMetaByClassName: func(_ *sdkMeta.GetCmdbMetaParams) (*sdkMeta.GetCmdbMetaOK, error) {
+						return nil, errBoom
+					},
+				},
+			},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{err: errors.Wrap(errBoom, errGetMetaFailed)},
+		},
+		"UnknownField": {
+			reason: "Fields the class does not have should be reported with similar fields.",
+			fields: fields{
+				table: &fake.MockTableClient{MockGetTableItems: tableItems(map[string]interface{}{"name": testName, "ram": "2048"})},
+				meta:  &fake.MockMetaClient{MockGetCmdbMetaByClassName: metaAttributes("name", "ram", "ram_size")},
+			},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID), withValues(map[string]string{"ra": "2048"}))},
+			want: want{err: errors.Wrap(errors.New("The field:ra is not recognized.\nAvailable fields that are similar to ra:\n[ram ram_size]"), errUnknownFields)},
+		},
+		"Drift": {
+			reason: "A CI whose fields differ from the desired values should not be up to date.",
+			fields: fields{
+				table: &fake.MockTableClient{MockGetTableItems: tableItems(map[string]interface{}{"name": testName, "ram": "1024"})},
+				meta:  &fake.MockMetaClient{MockGetCmdbMetaByClassName: metaAttributes("name", "ram")},
+			},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: false}},
+		},
+		"UpToDate": {
+			reason: "A CI whose fields have the desired values should be up to date.",
+			fields: fields{
+				table: &fake.MockTableClient{MockGetTableItems: tableItems(map[string]interface{}{"name": testName, "ram": "2048"})},
+				meta:  &fake.MockMetaClient{MockGetCmdbMetaByClassName: metaAttributes("name", "ram")},
+			},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, ready: &available},
+		},
+		"DeferredCreate": {
+			reason: "The creation of a CI should be deferred while the write window is closed.",
+			fields: fields{gate: closed},
+			args:   args{ctx: context.Background(), mg: ci()},
+			want:   want{o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}},
+		},
+		"DeferredUpdate": {
+			reason: "The update of a drifted CI should be deferred while the write window is closed.",
+			fields: fields{
+				table: &fake.MockTableClient{MockGetTableItems: tableItems(map[string]interface{}{"name": testName, "ram": "1024"})},
+				meta:  &fake.MockMetaClient{MockGetCmdbMetaByClassName: metaAttributes("name", "ram")},
+				gate:  closed,
+			},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, ready: &deferredUpdate},
+		},
+		"DeferredChange": {
+			reason: "No change request should be opened for an update that is deferred while the write window is closed.",
+			fields: fields{
+				table: &fake.MockTableClient{
+					MockGetTableItems: func(p *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+						if p.TableName == tableChangeRequest {
+							return nil, errBoom
+						}
+						return tableItems(map[string]interface{}{"name": testName, "ram": "1024"})(p)
+					},
+				},
+				meta: &fake.MockMetaClient{MockGetCmdbMetaByClassName: metaAttributes("name", "ram")},
+				gate: closed,
+			},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID), func(cr *v1alpha1.CI) {
+				cr.Spec.ForProvider.ChangeControl = &apisv1alpha1.ChangeControl{}
+			})},
+			want: want{o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, ready: &deferredUpdate},
+		},
+		"PlannedCreate": {
+			reason: "A read only client should plan the creation of a missing CI and report it as up to date.",
+			fields: fields{readOnly: true},
+			args:   args{ctx: context.Background(), mg: ci()},
+			want: want{
+				o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+				planned: &v1alpha1.PlannedChange{
+					Operation: plannedCreate,
+					Payload:   `{"items":[{"className":"cmdb_ci_linux_server","result":null,"values":{"name":"web-1","ram":"2048"}}]}`,
+				},
+			},
+		},
+		"PlannedCreateRedacted": {
+			reason: "Values read from Secrets should be redacted from a planned change.",
+			fields: fields{readOnly: true, values: map[string]string{"serial_number": "S3CR3T"}, sensitive: []string{"serial_number"}},
+			args:   args{ctx: context.Background(), mg: ci()},
+			want: want{
+				o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+				planned: &v1alpha1.PlannedChange{
+					Operation: plannedCreate,
+					Payload:   `{"items":[{"className":"cmdb_ci_linux_server","result":null,"values":{"name":"web-1","ram":"2048","serial_number":"REDACTED"}}]}`,
+				},
+			},
+		},
+		"PlannedUpdate": {
+			reason: "A read only client should plan the update of a drifted CI and report it as up to date.",
+			fields: fields{
+				table:    &fake.MockTableClient{MockGetTableItems: tableItems(map[string]interface{}{"name": testName, "ram": "1024"})},
+				meta:     &fake.MockMetaClient{MockGetCmdbMetaByClassName: metaAttributes("name", "ram")},
+				readOnly: true,
+			},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{
+				o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+				planned: &v1alpha1.PlannedChange{
+					Operation: plannedUpdate,
+					Fields:    []string{"ram"},
+					Payload:   `{"items":[{"className":"cmdb_ci_linux_server","result":null,"values":{"name":"web-1","ram":"2048"}}]}`,
+				},
+				ready: &planned,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := external{serviceTable: tc.fields.table, serviceMeta: tc.fields.meta, log: logging.NewNopLogger(), record: event.NewNopRecorder(), readOnly: tc.fields.readOnly, gate: tc.fields.gate, values: tc.fields.values, sensitive: tc.fields.sensitive}
+			got, err := e.Observe(tc.args.ctx, tc.args.mg)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+			if cr, ok := tc.args.mg.(*v1alpha1.CI); ok {
+				if diff := cmp.Diff(tc.want.planned, cr.Status.AtProvider.PlannedChange, cmpopts.IgnoreFields(v1alpha1.PlannedChange{}, "PlannedTime")); diff != "" {
+					t.Errorf("\n%s\ne.Observe(...): -want planned change, +got planned change:\n%s\n", tc.reason, diff)
+				}
+				if tc.want.ready != nil {
+					if diff := cmp.Diff(*tc.want.ready, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+						t.Errorf("\n%s\ne.Observe(...): -want ready condition, +got ready condition:\n%s\n", tc.reason, diff)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	type fields struct {
+		idenRecon sdkIdenRecon.ClientService
+	}
+
+	type args struct {
+		ctx context.Context
+		mg  resource.Managed
+	}
+
+	type want struct {
+		mg     resource.Managed
+		c      managed.ExternalCreation
+		events []event.Event
+		err    error
+	}
+
+	cases := map[string]struct {
+		reason string
+		fields fields
+		args   args
+		want   want
+	}{
+		"NotCI": {
+			reason: "An error should be returned if the managed resource is not a CI.",
+			args:   args{ctx: context.Background(), mg: &resourcefake.Managed{}},
+			want:   want{mg: &resourcefake.Managed{}, err: errors.New(errNotCI)},
+		},
+		"CreateFailed": {
+			reason: "Errors creating the CI should be returned.",
+			fields: fields{idenRecon: &fake.MockIdenReconClient{
+				MockCreateIdentifyReconcile: func(_ *sdkIdenRecon.CreateIdentifyReconcileParams) (*sdkIdenRecon.CreateIdentifyReconcileCreated, error) {
+					return nil, errBoom
+				},
+			}},
+			args: args{ctx: context.Background(), mg: ci()},
+			want: want{
+				mg: ci(func(cr *v1alpha1.CI) {
+					cr.SetConditions(xpv1.Creating())
+				}),
+				err: errors.Wrap(errBoom, errCreateFailed),
+			},
+		},
+		"EmptyResult": {
+			reason: "An error should be returned if the Identification and Reconciliation API returns no items.",
+			fields: fields{idenRecon: &fake.MockIdenReconClient{MockCreateIdentifyReconcile: ireItems()}},
+			args:   args{ctx: context.Background(), mg: ci()},
+			want: want{
+				mg: ci(func(cr *v1alpha1.CI) {
+					cr.SetConditions(xpv1.Creating())
+				}),
+				err: errors.New(errShortResult),
+			},
+		},
+		"Created": {
+			reason: "The sys_id of a created CI should be its external name.",
+			fields: fields{idenRecon: &fake.MockIdenReconClient{MockCreateIdentifyReconcile: ireResult("INSERT")}},
+			args:   args{ctx: context.Background(), mg: ci()},
+			want: want{
+				mg: ci(withExternalName(testSysID), func(cr *v1alpha1.CI) {
+					cr.SetConditions(xpv1.Available())
+				}),
+				c:      managed.ExternalCreation{ConnectionDetails: managed.ConnectionDetails{}},
+				events: []event.Event{event.Normal(reasonCreatedCI, "Created CI "+testSysID)},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &recorder{}
+			e := external{serviceIdenRecon: tc.fields.idenRecon, log: logging.NewNopLogger(), record: r}
+			got, err := e.Create(tc.args.ctx, tc.args.mg)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.Create(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.c, got); diff != "" {
+				t.Errorf("\n%s\ne.Create(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.mg, tc.args.mg, test.EquateConditions()); diff != "" {
+				t.Errorf("\n%s\ne.Create(...): -want managed resource, +got managed resource:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.events, r.events); diff != "" {
+				t.Errorf("\n%s\ne.Create(...): -want events, +got events:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		mg  resource.Managed
+	}
+
+	type fields struct {
+		idenRecon sdkIdenRecon.ClientService
+		outdated  []string
+	}
+
+	type want struct {
+		u      managed.ExternalUpdate
+		events []event.Event
+		err    error
+	}
+
+	cases := map[string]struct {
+		reason string
+		fields fields
+		args   args
+		want   want
+	}{
+		"NotCI": {
+			reason: "An error should be returned if the managed resource is not a CI.",
+			args:   args{ctx: context.Background(), mg: &resourcefake.Managed{}},
+			want:   want{err: errors.New(errNotCI)},
+		},
+		"UpdateFailed": {
+			reason: "Errors updating the CI should be returned, marked as transient if they are.",
+			fields: fields{idenRecon: &fake.MockIdenReconClient{
+				MockCreateIdentifyReconcile: func(_ *sdkIdenRecon.CreateIdentifyReconcileParams) (*sdkIdenRecon.CreateIdentifyReconcileCreated, error) {
+					return nil, &runtime.APIError{Code: http.StatusBadGateway}
+				},
+			}},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{err: errors.Wrap(clients.Annotate(&runtime.APIError{Code: http.StatusBadGateway}), errCreateFailed)},
+		},
+		"EmptyResult": {
+			reason: "An error should be returned if the Identification and Reconciliation API returns no items.",
+			fields: fields{idenRecon: &fake.MockIdenReconClient{MockCreateIdentifyReconcile: ireItems()}},
+			args:   args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want:   want{err: errors.New(errShortResult)},
+		},
+		"Updated": {
+			reason: "A CI should be updated with the Identification and Reconciliation API, and its drifted fields reported.",
+			fields: fields{
+				idenRecon: &fake.MockIdenReconClient{MockCreateIdentifyReconcile: ireResult("UPDATE")},
+				outdated:  []string{"os", "ram"},
+			},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{
+				u: managed.ExternalUpdate{ConnectionDetails: managed.ConnectionDetails{}},
+				events: []event.Event{
+					event.Normal(reasonUpdatedCI, "Updated CI "+testSysID),
+					event.Normal(reasonCorrectedDrift, "Corrected fields os, ram of CI "+testSysID),
+				},
+			},
+		},
+		"NoChange": {
+			reason: "A CI left unchanged by the Identification and Reconciliation API should not be reported as corrected.",
+			fields: fields{
+				idenRecon: &fake.MockIdenReconClient{MockCreateIdentifyReconcile: ireResult("NO_CHANGE")},
+				outdated:  []string{"ram"},
+			},
+			args: args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+			want: want{
+				u:      managed.ExternalUpdate{ConnectionDetails: managed.ConnectionDetails{}},
+				events: []event.Event{event.Normal(reasonUnchangedCI, "CI "+testSysID+" was already up to date")},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &recorder{}
+			e := external{serviceIdenRecon: tc.fields.idenRecon, log: logging.NewNopLogger(), record: r, outdated: tc.fields.outdated}
+			got, err := e.Update(tc.args.ctx, tc.args.mg)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.Update(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.u, got); diff != "" {
+				t.Errorf("\n%s\ne.Update(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.events, r.events); diff != "" {
+				t.Errorf("\n%s\ne.Update(...): -want events, +got events:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		mg  resource.Managed
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"NotCI": {
+			reason: "An error should be returned if the managed resource is not a CI.",
+			args:   args{ctx: context.Background(), mg: &resourcefake.Managed{}},
+			want:   errors.New(errNotCI),
+		},
+		"Deleted": {
+			reason: "Deleting a CI should leave it in ServiceNow.",
+			args:   args{ctx: context.Background(), mg: ci(withExternalName(testSysID))},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := external{log: logging.NewNopLogger(), record: event.NewNopRecorder()}
+			err := e.Delete(tc.args.ctx, tc.args.mg)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.Delete(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// A recorder records the events of a test.
+type recorder struct {
+	events []event.Event
+}
+
+func (r *recorder) Event(_ kruntime.Object, e event.Event) {
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) WithAnnotations(_ ...string) event.Recorder {
+	return r
+}
@@ -0,0 +1,76 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package idenrecon
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// Reasons of the events recorded on a CI for each change the provider makes,
+// or refuses to make, to the CMDB.
+const (
+	reasonCreatedCI      event.Reason = "CreatedCI"
+	reasonUpdatedCI      event.Reason = "UpdatedCI"
+	reasonUnchangedCI    event.Reason = "UnchangedCI"
+	reasonCorrectedDrift event.Reason = "CorrectedDrift"
+	reasonRejectedValues event.Reason = "RejectedValues"
+	reasonReleasedCI     event.Reason = "ReleasedCI"
+	reasonPlannedChange  event.Reason = "PlannedChange"
+
+	reasonRequestedChange   event.Reason = "RequestedChange"
+	reasonRejectedChange    event.Reason = "RejectedChange"
+	reasonImplementedChange event.Reason = "ImplementedChange"
+)
+
+// Operations planned in dry run mode, or deferred until the write window
+// opens.
+const (
+	plannedCreate = "Create"
+	plannedUpdate = "Update"
+
+	msgPlannedCreate  = "CI would be created, but changes to ServiceNow are disabled"
+	msgDeferredCreate = "CI will be created once the write window of the ProviderConfig opens"
+	msgPlannedUpdate  = "CI would be updated, but changes to ServiceNow are disabled"
+	msgDeferredUpdate = "CI will be updated once the write window of the ProviderConfig opens"
+	msgPendingChange  = "CI will be updated once its change request is approved"
+)
+
+// recordSubmission records the outcome of an Identification and
+// Reconciliation API submission.
+func recordSubmission(r event.Recorder, mg resource.Managed, operation, sysID string) {
+	switch operation {
+	case operationInsert:
+		r.Event(mg, event.Normal(reasonCreatedCI, fmt.Sprintf("Created CI %s", sysID)))
+	case operationUpdate:
+		r.Event(mg, event.Normal(reasonUpdatedCI, fmt.Sprintf("Updated CI %s", sysID)))
+	case operationNoChange:
+		r.Event(mg, event.Normal(reasonUnchangedCI, fmt.Sprintf("CI %s was already up to date", sysID)))
+	default:
+		r.Event(mg, event.Normal(reasonUpdatedCI, fmt.Sprintf("Submitted CI %s, operation %s", sysID, operation)))
+	}
+}
+
+// recordDrift records the correction of CI fields that were changed outside
+// of the provider.
+func recordDrift(r event.Recorder, mg resource.Managed, sysID string, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	r.Event(mg, event.Normal(reasonCorrectedDrift, fmt.Sprintf("Corrected fields %s of CI %s", strings.Join(fields, ", "), sysID)))
+}
+
+// recordPlan records a change that would be made to ServiceNow if changes
+// were not disabled.
+func recordPlan(r event.Recorder, mg resource.Managed, operation string, fields []string, payload string) {
+	msg := fmt.Sprintf("Would %s CI with payload %s", strings.ToLower(operation), payload)
+	if len(fields) > 0 {
+		msg = fmt.Sprintf("Would %s fields %s of CI with payload %s", strings.ToLower(operation), strings.Join(fields, ", "), payload)
+	}
+	r.Event(mg, event.Normal(reasonPlannedChange, msg))
+}
@@ -0,0 +1,47 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package idenrecon
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+)
+
+// metadataValues returns the CI values set by the labels and annotations of
+// the supplied CI. Annotations take precedence over labels, and annotations
+// prefixed with v1alpha1.AnnotationKeyFieldPrefix over mapped annotations.
+func metadataValues(cr *v1alpha1.CI, cfg clients.Config) map[string]string {
+	values := map[string]string{}
+	setMapped(values, cr.GetLabels(), cfg.LabelFields)
+	setMapped(values, cr.GetAnnotations(), cfg.AnnotationFields)
+	for k, v := range cr.GetAnnotations() {
+		if f := strings.TrimPrefix(k, v1alpha1.AnnotationKeyFieldPrefix); f != k && f != "" {
+			values[f] = v
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// setMapped sets the fields the supplied mapping maps the keys of the supplied
+// metadata to. Keys are applied in order, so that the last of several keys
+// mapped to the same field consistently wins.
+func setMapped(values, metadata, mapping map[string]string) {
+	keys := make([]string, 0, len(mapping))
+	for k := range mapping {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v, ok := metadata[k]; ok {
+			values[mapping[k]] = v
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package idenrecon
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+)
+
+func withMetadata(labels, annotations map[string]string) ciModifier {
+	return func(cr *v1alpha1.CI) {
+		cr.SetLabels(labels)
+		cr.SetAnnotations(annotations)
+	}
+}
+
+func TestMetadataValues(t *testing.T) {
+	cfg := clients.Config{
+		LabelFields:      map[string]string{"example.org/owner": "owned_by", "example.org/env": "environment"},
+		AnnotationFields: map[string]string{"example.org/cost-center": "cost_center", "example.org/team": "owned_by"},
+	}
+
+	cases := map[string]struct {
+		reason string
+		cr     *v1alpha1.CI
+		want   map[string]string
+	}{
+		"NoMetadata": {
+			reason: "A CI without mapped labels or annotations should have no values set by them.",
+			cr:     ci(withMetadata(map[string]string{"app": "web"}, nil)),
+		},
+		"Mapped": {
+			reason: "Mapped labels and annotations should set their fields.",
+			cr: ci(withMetadata(
+				map[string]string{"example.org/env": "prod", "app": "web"},
+				map[string]string{"example.org/cost-center": "1234"},
+			)),
+			want: map[string]string{"environment": "prod", "cost_center": "1234"},
+		},
+		"Precedence": {
+			reason: "Annotations should take precedence over labels, and prefixed annotations over mapped annotations.",
+			cr: ci(withMetadata(
+				map[string]string{"example.org/owner": "label", "example.org/env": "prod"},
+				map[string]string{"example.org/team": "annotation", v1alpha1.AnnotationKeyFieldPrefix + "environment": "staging"},
+			)),
+			want: map[string]string{"owned_by": "annotation", "environment": "staging"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := metadataValues(tc.cr, cfg)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nmetadataValues(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestParameters(t *testing.T) {
+	e := external{
+		defaults: map[string]string{"owned_by": "platform", "ram": "1024"},
+		values:   map[string]string{"serial_number": "S3CR3T", "ram": "4096"},
+	}
+	cr := ci()
+
+	got := e.parameters(cr)
+	want := map[string]string{"owned_by": "platform", "ram": "4096", "serial_number": "S3CR3T"}
+	if diff := cmp.Diff(want, got.Values); diff != "" {
+		t.Errorf("parameters(...): -want values, +got values:\n%s\n", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"ram": "2048"}, cr.Spec.ForProvider.Values); diff != "" {
+		t.Errorf("parameters(...): must not change the CI: -want values, +got values:\n%s\n", diff)
+	}
+}
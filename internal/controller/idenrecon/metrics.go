@@ -0,0 +1,122 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package idenrecon
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+)
+
+const (
+	listTimeout = 10 * time.Second
+
+	errListCIs = "cannot list CIs for metrics"
+)
+
+// Operations reported by the Identification and Reconciliation API, in
+// addition to operationError for failed requests.
+const (
+	operationInsert   = "INSERT"
+	operationUpdate   = "UPDATE"
+	operationNoChange = "NO_CHANGE"
+	operationError    = "ERROR"
+)
+
+var (
+	ireOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "cmdb",
+		Subsystem: "ire",
+		Name:      "operations_total",
+		Help:      "Number of CIs sent to the Identification and Reconciliation API, by class and resulting operation (INSERT, UPDATE, NO_CHANGE or ERROR).",
+	}, []string{"provider_config", "class", "operation"})
+
+	ciDesc = prometheus.NewDesc("cmdb_cis",
+		"Number of CI managed resources, by class and by the status of their Ready and Synced conditions.",
+		[]string{"class", "ready", "synced"}, nil)
+)
+
+func init() {
+	metrics.Registry.MustRegister(ireOperations)
+}
+
+// recordOperation counts an Identification and Reconciliation request for
+// the supplied CI.
+func recordOperation(cr *v1alpha1.CI, operation string) {
+	pc := ""
+	if ref := cr.GetProviderConfigReference(); ref != nil {
+		pc = ref.Name
+	}
+	if operation == "" {
+		operation = operationError
+	}
+	ireOperations.WithLabelValues(pc, cr.Spec.ForProvider.ClassName, strings.ToUpper(operation)).Inc()
+}
+
+// A ciCollector reports the number of CIs by class and condition whenever
+// metrics are scraped.
+type ciCollector struct {
+	kube client.Client
+	log  logging.Logger
+}
+
+// registerCICollector registers a ciCollector with the controller-runtime
+// metrics registry, unless one is already registered.
+func registerCICollector(kube client.Client, log logging.Logger) error {
+	err := metrics.Registry.Register(&ciCollector{kube: kube, log: log})
+	if _, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		return nil
+	}
+	return err
+}
+
+// Describe sends the descriptor of the CI gauge.
+func (c *ciCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ciDesc
+}
+
+// Collect counts the CIs known to the manager's cache.
+func (c *ciCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	l := &v1alpha1.CIList{}
+	if err := c.kube.List(ctx, l); err != nil {
+		c.log.Debug(errListCIs, "error", err)
+		return
+	}
+
+	type key struct{ class, ready, synced string }
+	counts := map[key]int{}
+	for i := range l.Items {
+		cr := &l.Items[i]
+		k := key{
+			class:  cr.Spec.ForProvider.ClassName,
+			ready:  string(conditionStatus(cr, xpv1.TypeReady)),
+			synced: string(conditionStatus(cr, xpv1.TypeSynced)),
+		}
+		counts[k]++
+	}
+
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(ciDesc, prometheus.GaugeValue, float64(n), k.class, k.ready, k.synced)
+	}
+}
+
+// conditionStatus returns the status of the supplied condition type, which
+// is Unknown if the CI does not have the condition.
+func conditionStatus(cr *v1alpha1.CI, ct xpv1.ConditionType) corev1.ConditionStatus {
+	return cr.GetCondition(ct).Status
+}
@@ -0,0 +1,132 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package idenrecon
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+)
+
+const (
+	errGetValueSecret    = "cannot get Secret of CI value"
+	errGetValueConfigMap = "cannot get ConfigMap of CI value"
+	errNoValueSource     = "exactly one of secretKeyRef and configMapKeyRef must be set"
+	errValueKeyNotFound  = "key is not found"
+	errIndexValuesFrom   = "cannot index CIs by the objects their values are read from"
+	errListValueCIs      = "cannot list CIs whose values are read from an object"
+)
+
+// Indexes of CIs by the namespaced names of the Secrets and ConfigMaps their
+// values are read from.
+const (
+	indexValueSecrets    = "spec.forProvider.valuesFrom.secretKeyRef"
+	indexValueConfigMaps = "spec.forProvider.valuesFrom.configMapKeyRef"
+)
+
+// resolveValues returns the values of the supplied CI that are read from
+// Secrets and ConfigMaps, and the fields whose values are read from Secrets.
+func resolveValues(ctx context.Context, kube client.Client, cr *v1alpha1.CI) (map[string]string, []string, error) {
+	if len(cr.Spec.ForProvider.ValuesFrom) == 0 {
+		return nil, nil, nil
+	}
+
+	values := make(map[string]string, len(cr.Spec.ForProvider.ValuesFrom))
+	var sensitive []string
+	for _, v := range cr.Spec.ForProvider.ValuesFrom {
+		switch {
+		case v.SecretKeyRef != nil && v.ConfigMapKeyRef == nil:
+			ref := v.SecretKeyRef
+			s := &corev1.Secret{}
+			if err := kube.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, s); err != nil {
+				return nil, nil, errors.Wrapf(err, "%s %s", errGetValueSecret, v.Field)
+			}
+			b, ok := s.Data[ref.Key]
+			if !ok {
+				return nil, nil, errors.Errorf("%s %s: %s %s", errGetValueSecret, v.Field, errValueKeyNotFound, ref.Key)
+			}
+			values[v.Field] = string(b)
+			sensitive = append(sensitive, v.Field)
+		case v.ConfigMapKeyRef != nil && v.SecretKeyRef == nil:
+			ref := v.ConfigMapKeyRef
+			cm := &corev1.ConfigMap{}
+			if err := kube.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cm); err != nil {
+				return nil, nil, errors.Wrapf(err, "%s %s", errGetValueConfigMap, v.Field)
+			}
+			s, ok := cm.Data[ref.Key]
+			if !ok {
+				return nil, nil, errors.Errorf("%s %s: %s %s", errGetValueConfigMap, v.Field, errValueKeyNotFound, ref.Key)
+			}
+			values[v.Field] = s
+		default:
+			return nil, nil, errors.Errorf("%s: %s", v.Field, errNoValueSource)
+		}
+	}
+	return values, sensitive, nil
+}
+
+// setupValueWatches requeues CIs when the Secrets and ConfigMaps their values
+// are read from change.
+func setupValueWatches(mgr ctrl.Manager, b *ctrl.Builder, log logging.Logger) error {
+	secrets := func(o client.Object) []string {
+		var keys []string
+		for _, v := range o.(*v1alpha1.CI).Spec.ForProvider.ValuesFrom {
+			if r := v.SecretKeyRef; r != nil {
+				keys = append(keys, types.NamespacedName{Namespace: r.Namespace, Name: r.Name}.String())
+			}
+		}
+		return keys
+	}
+	configMaps := func(o client.Object) []string {
+		var keys []string
+		for _, v := range o.(*v1alpha1.CI).Spec.ForProvider.ValuesFrom {
+			if r := v.ConfigMapKeyRef; r != nil {
+				keys = append(keys, types.NamespacedName{Namespace: r.Namespace, Name: r.Name}.String())
+			}
+		}
+		return keys
+	}
+
+	idx := mgr.GetFieldIndexer()
+	if err := idx.IndexField(context.Background(), &v1alpha1.CI{}, indexValueSecrets, secrets); err != nil {
+		return errors.Wrap(err, errIndexValuesFrom)
+	}
+	if err := idx.IndexField(context.Background(), &v1alpha1.CI{}, indexValueConfigMaps, configMaps); err != nil {
+		return errors.Wrap(err, errIndexValuesFrom)
+	}
+
+	b.Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(requestsFor(mgr.GetClient(), indexValueSecrets, log)))
+	b.Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(requestsFor(mgr.GetClient(), indexValueConfigMaps, log)))
+	return nil
+}
+
+// requestsFor returns a function that maps an object to requests for the CIs
+// that the supplied index associates with it.
+func requestsFor(kube client.Client, index string, log logging.Logger) handler.MapFunc {
+	return func(o client.Object) []reconcile.Request {
+		l := &v1alpha1.CIList{}
+		key := types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}.String()
+		if err := kube.List(context.Background(), l, client.MatchingFields{index: key}); err != nil {
+			log.Info(errListValueCIs, "object", key, "error", err)
+			return nil
+		}
+		reqs := make([]reconcile.Request, len(l.Items))
+		for i := range l.Items {
+			reqs[i] = reconcile.Request{NamespacedName: types.NamespacedName{Name: l.Items[i].GetName()}}
+		}
+		return reqs
+	}
+}
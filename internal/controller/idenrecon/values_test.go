@@ -0,0 +1,106 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package idenrecon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+)
+
+func withValuesFrom(v ...v1alpha1.ValueFromSource) ciModifier {
+	return func(cr *v1alpha1.CI) { cr.Spec.ForProvider.ValuesFrom = v }
+}
+
+func TestResolveValues(t *testing.T) {
+	secretRef := v1alpha1.ValueFromSource{
+		Field: "serial_number",
+		SecretKeyRef: &xpv1.SecretKeySelector{
+			SecretReference: xpv1.SecretReference{Namespace: "default", Name: "asset"},
+			Key:             "serial",
+		},
+	}
+	configMapRef := v1alpha1.ValueFromSource{
+		Field:           "ip_address",
+		ConfigMapKeyRef: &v1alpha1.ConfigMapKeySelector{Namespace: "default", Name: "network", Key: "ip"},
+	}
+	get := func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+		switch o := obj.(type) {
+		case *corev1.Secret:
+			o.Data = map[string][]byte{"serial": []byte("S3CR3T")}
+		case *corev1.ConfigMap:
+			o.Data = map[string]string{"ip": "10.0.0.1"}
+		}
+		return nil
+	}
+
+	type want struct {
+		values    map[string]string
+		sensitive []string
+		err       error
+	}
+
+	cases := map[string]struct {
+		reason string
+		kube   client.Client
+		cr     *v1alpha1.CI
+		want   want
+	}{
+		"NoValuesFrom": {
+			reason: "A CI without valuesFrom should have no resolved values.",
+			cr:     ci(),
+		},
+		"Resolved": {
+			reason: "Values should be read from Secrets and ConfigMaps, and those from Secrets reported as sensitive.",
+			kube:   &test.MockClient{MockGet: get},
+			cr:     ci(withValuesFrom(secretRef, configMapRef)),
+			want: want{
+				values:    map[string]string{"serial_number": "S3CR3T", "ip_address": "10.0.0.1"},
+				sensitive: []string{"serial_number"},
+			},
+		},
+		"GetSecretFailed": {
+			reason: "Errors getting a Secret should be returned.",
+			kube:   &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+			cr:     ci(withValuesFrom(secretRef)),
+			want:   want{err: errors.Wrapf(errBoom, "%s %s", errGetValueSecret, "serial_number")},
+		},
+		"KeyNotFound": {
+			reason: "A missing key should be reported.",
+			kube:   &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+			cr:     ci(withValuesFrom(configMapRef)),
+			want:   want{err: errors.Errorf("%s %s: %s %s", errGetValueConfigMap, "ip_address", errValueKeyNotFound, "ip")},
+		},
+		"NoSource": {
+			reason: "A value without exactly one source should be rejected.",
+			cr:     ci(withValuesFrom(v1alpha1.ValueFromSource{Field: "ram"})),
+			want:   want{err: errors.Errorf("%s: %s", "ram", errNoValueSource)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			values, sensitive, err := resolveValues(context.Background(), tc.kube, tc.cr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nresolveValues(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.values, values); diff != "" {
+				t.Errorf("\n%s\nresolveValues(...): -want values, +got values:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.sensitive, sensitive); diff != "" {
+				t.Errorf("\n%s\nresolveValues(...): -want sensitive fields, +got sensitive fields:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package inventory
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlevent "sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/connection"
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	sdkIdenRecon "github.com/anka-software/cmdb-sdk/pkg/client/cmdb"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
+	"github.com/crossplane/provider-cmdb/internal/clients/schedule"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+	"github.com/crossplane/provider-cmdb/internal/controller/features"
+	"github.com/crossplane/provider-cmdb/internal/controller/ratelimit"
+	"github.com/crossplane/provider-cmdb/internal/tracing"
+)
+
+const (
+	errNotClusterInventory = "managed resource is not a ClusterInventory custom resource"
+	errListInventories     = "cannot list ClusterInventories"
+)
+
+// setupClusterInventory adds a controller that reconciles ClusterInventory
+// managed resources.
+func setupClusterInventory(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.ClusterInventoryGroupKind)
+
+	cps := []managed.ConnectionPublisher{managed.NewAPISecretPublisher(mgr.GetClient(), mgr.GetScheme())}
+	if o.Features.Enabled(features.EnableAlphaExternalSecretStores) {
+		cps = append(cps, connection.NewDetailsManager(mgr.GetClient(), apisv1alpha1.StoreConfigGroupVersionKind))
+	}
+
+	log := o.Logger.WithValues("controller", name)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+
+	r := managed.NewReconciler(mgr,
+		resource.ManagedKind(v1alpha1.ClusterInventoryGroupVersionKind),
+		managed.WithExternalConnecter(&connector{
+			kube:                  mgr.GetClient(),
+			usage:                 resource.NewProviderConfigUsageTracker(mgr.GetClient(), &apisv1alpha1.ProviderConfigUsage{}),
+			newServiceFnIdenRecon: idenrecon.NewIdenReconClient,
+			log:                   log,
+			record:                recorder,
+			dryRun:                o.Features.Enabled(features.DryRun),
+		}),
+		// The external name is the sys_id of the cluster CI, set once it is
+		// registered.
+		managed.WithInitializers(),
+		managed.WithLogger(log),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithRecorder(recorder),
+		managed.WithConnectionPublishers(cps...))
+
+	// Changes to the objects of the cluster requeue every ClusterInventory.
+	// Updates that do not change the fields collect reads, e.g. of the
+	// status of a Deployment or the heartbeat of a Node, are ignored.
+	enqueue := handler.EnqueueRequestsFromMapFunc(inventories(mgr.GetClient(), log))
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		For(&v1alpha1.ClusterInventory{})
+	for _, obj := range []client.Object{&corev1.Node{}, &corev1.Namespace{}, &appsv1.Deployment{}, &appsv1.StatefulSet{}, &corev1.Service{}} {
+		b = b.Watches(&source.Kind{Type: obj}, enqueue, builder.WithPredicates(inventoryChanged()))
+	}
+
+	return b.Complete(ratelimiter.NewReconciler(name, tracing.NewReconciler(name, ratelimit.NewReconciler(mgr.GetClient(), resource.ManagedKind(v1alpha1.ClusterInventoryGroupVersionKind), r, log)), o.GlobalRateLimiter))
+}
+
+// inventories returns a function that maps any object to requests for every
+// ClusterInventory.
+func inventories(kube client.Client, log logging.Logger) handler.MapFunc {
+	return func(_ client.Object) []reconcile.Request {
+		l := &v1alpha1.ClusterInventoryList{}
+		if err := kube.List(context.Background(), l); err != nil {
+			log.Info(errListInventories, "error", err)
+			return nil
+		}
+		reqs := make([]reconcile.Request, len(l.Items))
+		for i := range l.Items {
+			reqs[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&l.Items[i])}
+		}
+		return reqs
+	}
+}
+
+// inventoryChanged returns a predicate that accepts updates of the fields of
+// an object that collect reads. Objects that are created or deleted are always
+// accepted.
+func inventoryChanged() predicate.Predicate {
+	return predicate.Funcs{UpdateFunc: func(e ctrlevent.UpdateEvent) bool {
+		return !reflect.DeepEqual(inventoriedFields(e.ObjectOld), inventoriedFields(e.ObjectNew))
+	}}
+}
+
+// inventoriedFields returns the fields of the supplied object that collect reads,
+// besides its name.
+func inventoriedFields(obj client.Object) interface{} {
+	switch o := obj.(type) {
+	case *corev1.Node:
+		return []string{internalIP(*o), o.Status.NodeInfo.KubeletVersion, o.Status.NodeInfo.OSImage}
+	case *corev1.Namespace:
+		// Namespaces are selected by their labels.
+		return o.GetLabels()
+	case *corev1.Service:
+		return o.Spec.ClusterIP
+	}
+	return nil
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube                  client.Client
+	usage                 resource.Tracker
+	newServiceFnIdenRecon func(cfg clients.Config) sdkIdenRecon.ClientService
+	log                   logging.Logger
+	record                event.Recorder
+	dryRun                bool
+}
+
+// Connect produces an ExternalClient for the ProviderConfig of the supplied
+// ClusterInventory.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	cr, ok := mg.(*v1alpha1.ClusterInventory)
+	if !ok {
+		return nil, errors.New(errNotClusterInventory)
+	}
+
+	if err := c.usage.Track(ctx, mg); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	cfg, err := clients.GetConfig(ctx, c.kube, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	log := c.log.WithValues("resource", cr.GetName(), "cluster", cr.Spec.ForProvider.ClusterName)
+
+	return &external{kube: c.kube, registrar: registrar{serviceIdenRecon: c.newServiceFnIdenRecon(*cfg), log: log, record: c.record, readOnly: c.dryRun || cfg.ReadOnly, gate: schedule.NewGate(*cfg, table.NewTableClient(*cfg))}}, nil
+}
+
+// An external registers the objects of the cluster as CIs.
+type external struct {
+	registrar
+	kube client.Client
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.ClusterInventory)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotClusterInventory)
+	}
+	return c.observe(ctx, cr, c.collector(cr))
+}
+
+func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.ClusterInventory)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotClusterInventory)
+	}
+	return c.create(ctx, cr, c.collector(cr))
+}
+
+func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.ClusterInventory)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotClusterInventory)
+	}
+	return c.update(ctx, cr, c.collector(cr))
+}
+
+func (c *external) Delete(ctx context.Context, mg resource.Managed) error {
+	cr, ok := mg.(*v1alpha1.ClusterInventory)
+	if !ok {
+		return errors.New(errNotClusterInventory)
+	}
+	return c.delete(ctx, cr)
+}
+
+// collector returns the collector of the objects of the cluster of the
+// supplied ClusterInventory.
+func (c *external) collector(cr *v1alpha1.ClusterInventory) collector {
+	return &clusterCollector{kube: c.kube, params: &cr.Spec.ForProvider}
+}
+
+// A clusterCollector collects the objects of the cluster.
+type clusterCollector struct {
+	kube   client.Client
+	params *v1alpha1.ClusterInventoryParameters
+}
+
+func (c *clusterCollector) collect(ctx context.Context) (*inventory, error) {
+	return collect(ctx, c.kube, c.params)
+}
+
+// externalName returns the sys_id of the cluster, which is always the first
+// CI of the inventory.
+func (c *clusterCollector) externalName(sysIDs []string) string {
+	return sysIDs[0]
+}
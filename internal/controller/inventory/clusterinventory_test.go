@@ -0,0 +1,273 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package inventory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrlevent "sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+const testCluster = "prod"
+
+func objects() []client.Object {
+	return []client.Object{
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+				NodeInfo:  corev1.NodeSystemInfo{KubeletVersion: "v1.23.0"},
+			},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"cmdb": "true"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "frontend"}},
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "db"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "frontend"}, Spec: corev1.ServiceSpec{ClusterIP: "10.96.0.10"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "coredns"}},
+	}
+}
+
+func inventoryCR() *v1alpha1.ClusterInventory {
+	return &v1alpha1.ClusterInventory{
+		ObjectMeta: metav1.ObjectMeta{Name: testCluster},
+		Spec: v1alpha1.ClusterInventorySpec{ForProvider: v1alpha1.ClusterInventoryParameters{
+			SysParamDataSource: "ServiceNow",
+			ClusterName:        testCluster,
+			NamespaceSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"cmdb": "true"}},
+		}},
+	}
+}
+
+func TestInventoryChanged(t *testing.T) {
+	node := func(ip, kubelet string, conditions ...corev1.NodeCondition) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: ip}},
+				NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: kubelet},
+				Conditions: conditions,
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		reason string
+		old    client.Object
+		new    client.Object
+		want   bool
+	}{
+		"NodeUpgraded": {
+			reason: "An update of the kubelet version of a Node should be accepted, although it does not change its generation.",
+			old:    node("10.0.0.1", "v1.23.0"),
+			new:    node("10.0.0.1", "v1.24.0"),
+			want:   true,
+		},
+		"NodeAddress": {
+			reason: "An update of the internal IP of a Node should be accepted.",
+			old:    node("10.0.0.1", "v1.23.0"),
+			new:    node("10.0.0.2", "v1.23.0"),
+			want:   true,
+		},
+		"NodeHeartbeat": {
+			reason: "An update of a Node that does not change the fields that are inventoried should be ignored.",
+			old:    node("10.0.0.1", "v1.23.0"),
+			new:    node("10.0.0.1", "v1.23.0", corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}),
+			want:   false,
+		},
+		"NamespaceLabels": {
+			reason: "An update of the labels of a Namespace should be accepted, since they select it.",
+			old:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+			new:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"cmdb": "true"}}},
+			want:   true,
+		},
+		"ServiceIP": {
+			reason: "An update of the cluster IP of a Service should be accepted.",
+			old:    &corev1.Service{Spec: corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone}},
+			new:    &corev1.Service{Spec: corev1.ServiceSpec{ClusterIP: "10.96.0.10"}},
+			want:   true,
+		},
+		"DeploymentStatus": {
+			reason: "An update of the status of a Deployment should be ignored.",
+			old:    &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "frontend"}},
+			new:    &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "frontend"}, Status: appsv1.DeploymentStatus{ReadyReplicas: 1}},
+			want:   false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := inventoryChanged().Update(ctrlevent.UpdateEvent{ObjectOld: tc.old, ObjectNew: tc.new})
+			if got != tc.want {
+				t.Errorf("\n%s\ninventoryChanged().Update(...): want %t, got %t", tc.reason, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	kube := fake.NewClientBuilder().WithScheme(s).WithObjects(objects()...).Build()
+
+	inv, err := collect(context.Background(), kube, &inventoryCR().Spec.ForProvider)
+	if err != nil {
+		t.Fatalf("collect(...): %v", err)
+	}
+
+	type ci struct{ Class, Name string }
+	var got []ci
+	for _, item := range inv.items {
+		got = append(got, ci{Class: item.ClassName, Name: item.Values[fieldName]})
+	}
+	want := []ci{
+		{Class: classCluster, Name: "prod"},
+		{Class: classNode, Name: "prod/node-1"},
+		{Class: classNamespace, Name: "prod/web"},
+		{Class: classDeployment, Name: "prod/web/frontend"},
+		{Class: classStatefulSet, Name: "prod/web/db"},
+		{Class: classService, Name: "prod/web/frontend"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("collect(...): -want CIs, +got CIs:\n%s\n", diff)
+	}
+
+	wantRelations := []idenrecon.Relation{
+		{Parent: 0, Child: 1, Type: idenrecon.RelationContains},
+		{Parent: 0, Child: 2, Type: idenrecon.RelationContains},
+		{Parent: 2, Child: 3, Type: idenrecon.RelationContains},
+		{Parent: 2, Child: 4, Type: idenrecon.RelationContains},
+		{Parent: 2, Child: 5, Type: idenrecon.RelationContains},
+	}
+	if diff := cmp.Diff(wantRelations, inv.relations); diff != "" {
+		t.Errorf("collect(...): -want relations, +got relations:\n%s\n", diff)
+	}
+	if got := inv.items[1].Values[fieldIPAddress]; got != "10.0.0.1" {
+		t.Errorf("collect(...): want node IP address 10.0.0.1, got %q", got)
+	}
+}
+
+// ciRecord returns the record of the CI of the supplied class and name.
+func ciRecord(sn *servicenow.Server, class, name string) servicenow.Record {
+	for _, r := range sn.Records(class) {
+		if r[servicenow.FieldName] == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// TestLifecycle registers the inventory of a cluster with a fake ServiceNow
+// instance, retires the CI of a deleted object, then retires every CI.
+func TestLifecycle(t *testing.T) {
+	sn := servicenow.NewServer()
+	defer sn.Close()
+
+	s := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(s)
+	kube := fake.NewClientBuilder().WithScheme(s).WithObjects(objects()...).Build()
+	ctx := context.Background()
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+
+	newExternal := func() *external {
+		return &external{kube: kube, registrar: registrar{serviceIdenRecon: idenrecon.NewIdenReconClient(cfg), log: logging.NewNopLogger(), record: event.NewNopRecorder()}}
+	}
+	cr := inventoryCR()
+
+	e := newExternal()
+	o, err := e.Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if diff := cmp.Diff(managed.ExternalObservation{}, o); diff != "" {
+		t.Errorf("Observe(...): unregistered inventory: -want, +got:\n%s\n", diff)
+	}
+	if _, err := e.Create(ctx, cr); err != nil {
+		t.Fatalf("Create(...): %v", err)
+	}
+	cluster, ok := sn.Get(meta.GetExternalName(cr))
+	if !ok || cluster["name"] != testCluster {
+		t.Errorf("Create(...): want the external name to be the sys_id of the cluster CI, got %v", cluster)
+	}
+	if got := len(sn.Records(servicenow.TableRelationships)); got != 5 {
+		t.Errorf("Create(...): want 5 relations, got %d", got)
+	}
+
+	e = newExternal()
+	o, err = e.Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if diff := cmp.Diff(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, o); diff != "" {
+		t.Errorf("Observe(...): registered inventory: -want, +got:\n%s\n", diff)
+	}
+
+	var deployment string
+	for _, ci := range cr.Status.AtProvider.CIs {
+		if ci.ClassName == classDeployment {
+			deployment = ci.Name
+		}
+	}
+	if err := kube.Delete(ctx, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "frontend"}}); err != nil {
+		t.Fatalf("kube.Delete(...): %v", err)
+	}
+
+	e = newExternal()
+	o, err = e.Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if o.ResourceUpToDate {
+		t.Errorf("Observe(...): want an inventory with a deleted object not to be up to date")
+	}
+	if _, err := e.Update(ctx, cr); err != nil {
+		t.Fatalf("Update(...): %v", err)
+	}
+	if r := ciRecord(sn, classDeployment, deployment); r[fieldInstallStatus] != installStatusRetired {
+		t.Errorf("Update(...): want the CI of the deleted deployment to be retired, got %v", r)
+	}
+	if got := len(cr.Status.AtProvider.CIs); got != 5 {
+		t.Errorf("Update(...): want 5 registered CIs, got %d", got)
+	}
+
+	cis := cr.Status.AtProvider.CIs
+	now := metav1.Now()
+	cr.SetDeletionTimestamp(&now)
+	if err := newExternal().Delete(ctx, cr); err != nil {
+		t.Fatalf("Delete(...): %v", err)
+	}
+	for _, ci := range cis {
+		if r := ciRecord(sn, ci.ClassName, ci.Name); r[fieldInstallStatus] != installStatusRetired {
+			t.Errorf("Delete(...): want CI %s to be retired, got %v", ci.Name, r)
+		}
+	}
+	o, err = newExternal().Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if diff := cmp.Diff(managed.ExternalObservation{}, o, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("Observe(...): retired inventory: -want, +got:\n%s\n", diff)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package inventory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/anka-software/cmdb-sdk/pkg/models"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
+)
+
+const (
+	errListNodes        = "cannot list nodes"
+	errListNamespaces   = "cannot list namespaces"
+	errListDeployments  = "cannot list deployments"
+	errListStatefulSets = "cannot list statefulsets"
+	errListServices     = "cannot list services"
+	errSelector         = "cannot parse namespace selector"
+	errHash             = "cannot hash inventory"
+)
+
+// Classes of the CIs registered for the objects of a cluster.
+const (
+	classCluster     = "cmdb_ci_kubernetes_cluster"
+	classNode        = "cmdb_ci_kubernetes_node"
+	classNamespace   = "cmdb_ci_kubernetes_namespace"
+	classDeployment  = "cmdb_ci_kubernetes_deployment"
+	classStatefulSet = "cmdb_ci_kubernetes_statefulset"
+	classService     = "cmdb_ci_kubernetes_service"
+)
+
+// Fields and values of the CIs.
+const (
+	fieldName              = "name"
+	fieldIPAddress         = "ip_address"
+	fieldKubeletVersion    = "kubelet_version"
+	fieldOSImage           = "os_image"
+	fieldInstallStatus     = "install_status"
+	fieldOperationalStatus = "operational_status"
+
+	installStatusInstalled       = "1"
+	installStatusRetired         = "7"
+	operationalStatusOperational = "1"
+	operationalStatusRetired     = "6"
+)
+
+// An inventory of the objects of a cluster or of managed resources, as the
+// payload of an Identification and Reconciliation API request.
+type inventory struct {
+	items     []*models.IdentifyReconcileItem
+	relations []idenrecon.Relation
+}
+
+// add adds a CI to the inventory, contained by the CI at the supplied index
+// unless it is negative, and returns its index.
+func (i *inventory) add(class, name string, values map[string]string, parent int) int {
+	v := map[string]string{
+		fieldName:              name,
+		fieldInstallStatus:     installStatusInstalled,
+		fieldOperationalStatus: operationalStatusOperational,
+	}
+	for k, val := range values {
+		if val != "" {
+			v[k] = val
+		}
+	}
+	i.items = append(i.items, &models.IdentifyReconcileItem{ClassName: class, Values: v})
+	idx := len(i.items) - 1
+	if parent >= 0 {
+		i.relations = append(i.relations, idenrecon.Relation{Parent: parent, Child: idx, Type: idenrecon.RelationContains})
+	}
+	return idx
+}
+
+// contains returns true if the inventory has a CI of the supplied class and
+// name.
+func (i *inventory) contains(class, name string) bool {
+	for _, item := range i.items {
+		if item.ClassName == class && item.Values[fieldName] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hash identifies the content of the inventory.
+func (i *inventory) hash() (string, error) {
+	b, err := json.Marshal(struct {
+		Items     []*models.IdentifyReconcileItem `json:"items"`
+		Relations []idenrecon.Relation            `json:"relations"`
+	}{Items: i.items, Relations: i.relations})
+	if err != nil {
+		return "", errors.Wrap(err, errHash)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// retirement returns a CI of the supplied class and name marked as retired.
+func retirement(class, name string) *models.IdentifyReconcileItem {
+	return &models.IdentifyReconcileItem{ClassName: class, Values: map[string]string{
+		fieldName:              name,
+		fieldInstallStatus:     installStatusRetired,
+		fieldOperationalStatus: operationalStatusRetired,
+	}}
+}
+
+// qualify returns the name of the CI of an object of the supplied cluster.
+// CI names are unique across the clusters registered in the CMDB.
+func qualify(cluster string, names ...string) string {
+	n := cluster
+	for _, s := range names {
+		n += "/" + s
+	}
+	return n
+}
+
+// collect returns the inventory of the cluster, in a stable order.
+func collect(ctx context.Context, kube client.Client, p *v1alpha1.ClusterInventoryParameters) (*inventory, error) { //nolint:gocyclo // A flat walk through the objects of the cluster.
+	inv := &inventory{}
+	cluster := inv.add(classCluster, p.ClusterName, nil, -1)
+
+	nodes := &corev1.NodeList{}
+	if err := kube.List(ctx, nodes); err != nil {
+		return nil, errors.Wrap(err, errListNodes)
+	}
+	sort.Slice(nodes.Items, func(i, j int) bool { return nodes.Items[i].GetName() < nodes.Items[j].GetName() })
+	for _, n := range nodes.Items {
+		inv.add(classNode, qualify(p.ClusterName, n.GetName()), map[string]string{
+			fieldIPAddress:      internalIP(n),
+			fieldKubeletVersion: n.Status.NodeInfo.KubeletVersion,
+			fieldOSImage:        n.Status.NodeInfo.OSImage,
+		}, cluster)
+	}
+
+	sel := labels.Everything()
+	if p.NamespaceSelector != nil {
+		s, err := metav1.LabelSelectorAsSelector(p.NamespaceSelector)
+		if err != nil {
+			return nil, errors.Wrap(err, errSelector)
+		}
+		sel = s
+	}
+	namespaces := &corev1.NamespaceList{}
+	if err := kube.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: sel}); err != nil {
+		return nil, errors.Wrap(err, errListNamespaces)
+	}
+	sort.Slice(namespaces.Items, func(i, j int) bool { return namespaces.Items[i].GetName() < namespaces.Items[j].GetName() })
+
+	for _, ns := range namespaces.Items {
+		name := ns.GetName()
+		parent := inv.add(classNamespace, qualify(p.ClusterName, name), nil, cluster)
+
+		deployments := &appsv1.DeploymentList{}
+		if err := kube.List(ctx, deployments, client.InNamespace(name)); err != nil {
+			return nil, errors.Wrap(err, errListDeployments)
+		}
+		sort.Slice(deployments.Items, func(i, j int) bool { return deployments.Items[i].GetName() < deployments.Items[j].GetName() })
+		for _, d := range deployments.Items {
+			inv.add(classDeployment, qualify(p.ClusterName, name, d.GetName()), nil, parent)
+		}
+
+		statefulSets := &appsv1.StatefulSetList{}
+		if err := kube.List(ctx, statefulSets, client.InNamespace(name)); err != nil {
+			return nil, errors.Wrap(err, errListStatefulSets)
+		}
+		sort.Slice(statefulSets.Items, func(i, j int) bool { return statefulSets.Items[i].GetName() < statefulSets.Items[j].GetName() })
+		for _, s := range statefulSets.Items {
+			inv.add(classStatefulSet, qualify(p.ClusterName, name, s.GetName()), nil, parent)
+		}
+
+		services := &corev1.ServiceList{}
+		if err := kube.List(ctx, services, client.InNamespace(name)); err != nil {
+			return nil, errors.Wrap(err, errListServices)
+		}
+		sort.Slice(services.Items, func(i, j int) bool { return services.Items[i].GetName() < services.Items[j].GetName() })
+		for _, s := range services.Items {
+			ip := s.Spec.ClusterIP
+			if ip == corev1.ClusterIPNone {
+				ip = ""
+			}
+			inv.add(classService, qualify(p.ClusterName, name, s.GetName()), map[string]string{fieldIPAddress: ip}, parent)
+		}
+	}
+
+	return inv, nil
+}
+
+// internalIP returns the internal IP address of the supplied node.
+func internalIP(n corev1.Node) string {
+	for _, a := range n.Status.Addresses {
+		if a.Type == corev1.NodeInternalIP {
+			return a.Address
+		}
+	}
+	return ""
+}
@@ -0,0 +1,398 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+// Package inventory registers the cluster the provider runs in, and the
+// managed resources of other providers, as CIs.
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	sdkIdenRecon "github.com/anka-software/cmdb-sdk/pkg/client/cmdb"
+	"github.com/anka-software/cmdb-sdk/pkg/models"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
+	"github.com/crossplane/provider-cmdb/internal/clients/schedule"
+	"github.com/crossplane/provider-cmdb/internal/tracing"
+)
+
+const (
+	errTrackPCUsage = "cannot track ProviderConfig usage"
+
+	errSyncFailed   = "cannot register CIs with Identification and Reconciliation API"
+	errRetireFailed = "cannot retire CIs with Identification and Reconciliation API"
+	errShortResult  = "Identification and Reconciliation API returned fewer items than were sent"
+	errSchedule     = "cannot evaluate the write window of the ProviderConfig"
+	errDeferred     = "CIs are retired once the write window of the ProviderConfig opens"
+	errTooManyCIs   = "inventory has more CIs than can be registered"
+)
+
+const (
+	// maxBatchItems is how many CIs are sent to the Identification and
+	// Reconciliation API in one request.
+	maxBatchItems = 100
+
+	// maxInventoryCIs is how many CIs an inventory may have. The CIs are
+	// kept in the status of the inventory, whose size etcd bounds.
+	maxInventoryCIs = 5000
+)
+
+// Reasons of the events recorded on a ClusterInventory or ResourceInventory.
+const (
+	reasonRegisteredCIs event.Reason = "RegisteredCIs"
+	reasonUnchangedCIs  event.Reason = "UnchangedCIs"
+	reasonRetiredCIs    event.Reason = "RetiredCIs"
+	reasonPlannedChange event.Reason = "PlannedChange"
+)
+
+const (
+	// operationNoChange is reported by the Identification and
+	// Reconciliation API for CIs that were already up to date.
+	operationNoChange = "NO_CHANGE"
+
+	msgPlannedRegister = "Would register %d CIs and retire %d CIs, but changes to ServiceNow are disabled"
+	msgPlannedRetire   = "Would retire %d CIs, but changes to ServiceNow are disabled"
+)
+
+// Setup adds controllers that reconcile ClusterInventory and ResourceInventory
+// managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	if err := setupClusterInventory(mgr, o); err != nil {
+		return err
+	}
+	return setupResourceInventory(mgr, o)
+}
+
+// An inventoried managed resource has the CIs of its inventory registered by
+// a registrar: a ClusterInventory or a ResourceInventory.
+type inventoried interface {
+	resource.Managed
+
+	GetSysParamDataSource() string
+	GetInventoryObservation() *v1alpha1.InventoryObservation
+}
+
+// A collector collects the inventory of an inventoried managed resource.
+type collector interface {
+	// collect returns the inventory of the managed resource.
+	collect(ctx context.Context) (*inventory, error)
+
+	// externalName returns the external name of the managed resource once
+	// the CIs with the supplied sys_ids, in the order of its inventory, are
+	// registered.
+	externalName(sysIDs []string) string
+}
+
+// A registrar registers the CIs of an inventory, and retires the CIs that are
+// no longer in it. Read only registrars report the changes they would make in
+// Observe, and report the inventory as up to date so that they are not made.
+type registrar struct {
+	serviceIdenRecon sdkIdenRecon.ClientService
+	log              logging.Logger
+	record           event.Recorder
+
+	// readOnly registrars report the changes they would make instead of
+	// making them.
+	readOnly bool
+
+	// gate defers changes outside the write windows of the ProviderConfig.
+	gate *schedule.Gate
+
+	// inventory collected by Observe, and registered by a subsequent Create
+	// or Update.
+	inventory *inventory
+}
+
+// observe collects the inventory of the supplied managed resource, and
+// reports whether it was registered as it is.
+func (r *registrar) observe(ctx context.Context, cr inventoried, c collector) (managed.ExternalObservation, error) {
+	o := cr.GetInventoryObservation()
+	if meta.WasDeleted(cr) {
+		return managed.ExternalObservation{ResourceExists: o.RetiredTime == nil}, nil
+	}
+
+	inv, err := c.collect(ctx)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	hash, err := inv.hash()
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	r.inventory = inv
+
+	exists := meta.GetExternalName(cr) != ""
+	upToDate := exists && hash == o.Hash
+
+	if r.readOnly {
+		if !upToDate {
+			r.plan(cr, inv, o.CIs)
+		}
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil
+	}
+
+	if !upToDate {
+		deferred, err := r.deferred(ctx, cr)
+		if err != nil {
+			return managed.ExternalObservation{}, err
+		}
+		if deferred {
+			r.log.Debug("Deferring registration of CIs until the write window opens", "cis", len(inv.items))
+			return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil
+		}
+	}
+
+	if exists {
+		cr.SetConditions(xpv1.Available())
+	}
+
+	return managed.ExternalObservation{ResourceExists: exists, ResourceUpToDate: upToDate}, nil
+}
+
+// create registers the inventory of the supplied managed resource.
+func (r *registrar) create(ctx context.Context, cr inventoried, c collector) (managed.ExternalCreation, error) {
+	cr.SetConditions(xpv1.Creating())
+
+	return managed.ExternalCreation{}, r.registerInventory(ctx, cr, c)
+}
+
+// update registers the changed inventory of the supplied managed resource.
+func (r *registrar) update(ctx context.Context, cr inventoried, c collector) (managed.ExternalUpdate, error) {
+	return managed.ExternalUpdate{}, r.registerInventory(ctx, cr, c)
+}
+
+// delete retires the CIs registered for the supplied managed resource.
+func (r *registrar) delete(ctx context.Context, cr inventoried) error {
+	cr.SetConditions(xpv1.Deleting())
+
+	o := cr.GetInventoryObservation()
+	if err := r.retire(ctx, cr, cr.GetSysParamDataSource(), o.CIs); err != nil {
+		return err
+	}
+
+	now := metav1.Now()
+	o.RetiredTime = &now
+	o.CIs = nil
+	return nil
+}
+
+// registerInventory registers the inventory collected by Observe, and retires
+// the CIs of objects that no longer exist.
+func (r *registrar) registerInventory(ctx context.Context, cr inventoried, c collector) error {
+	inv := r.inventory
+	if inv == nil {
+		var err error
+		if inv, err = c.collect(ctx); err != nil {
+			return err
+		}
+	}
+	hash, err := inv.hash()
+	if err != nil {
+		return err
+	}
+
+	o := cr.GetInventoryObservation()
+	cis, sysIDs, err := r.register(ctx, cr, cr.GetSysParamDataSource(), inv, o.CIs)
+	if err != nil {
+		return err
+	}
+
+	meta.SetExternalName(cr, c.externalName(sysIDs))
+	o.CIs = cis
+	o.Hash = hash
+	cr.SetConditions(xpv1.Available())
+	return nil
+}
+
+// deferred returns true if changes must be deferred until the write window
+// of the ProviderConfig opens.
+func (r *registrar) deferred(ctx context.Context, mg resource.Managed) (bool, error) {
+	open, err := r.gate.Open(ctx, mg)
+	if err != nil {
+		return false, errors.Wrap(err, errSchedule)
+	}
+	return !open, nil
+}
+
+// plan reports the changes registering the supplied inventory would make.
+func (r *registrar) plan(mg resource.Managed, inv *inventory, registered []v1alpha1.InventoryCI) {
+	r.log.Info("Not registering CIs in dry run mode", "cis", len(inv.items))
+	r.record.Event(mg, event.Normal(reasonPlannedChange, fmt.Sprintf(msgPlannedRegister, len(inv.items), len(retired(registered, inv)))))
+}
+
+// register registers the supplied inventory, and retires the registered CIs
+// that are no longer in it. It returns the CIs of the inventory, and their
+// sys_ids.
+func (r *registrar) register(ctx context.Context, mg resource.Managed, source string, inv *inventory, registered []v1alpha1.InventoryCI) ([]v1alpha1.InventoryCI, []string, error) {
+	if len(inv.items) > maxInventoryCIs {
+		return nil, nil, errors.Errorf("%s: %d CIs, at most %d", errTooManyCIs, len(inv.items), maxInventoryCIs)
+	}
+
+	gone := retired(registered, inv)
+	items := append([]*models.IdentifyReconcileItem{}, inv.items...)
+	for _, ci := range gone {
+		items = append(items, retirement(ci.ClassName, ci.Name))
+	}
+	if len(items) == 0 {
+		return nil, nil, nil
+	}
+
+	start := time.Now()
+	results, err := r.submit(ctx, source, items, inv.relations)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errSyncFailed)
+	}
+
+	cis := make([]v1alpha1.InventoryCI, len(inv.items))
+	sysIDs := make([]string, len(inv.items))
+	changed := 0
+	for i, item := range inv.items {
+		cis[i] = v1alpha1.InventoryCI{ClassName: item.ClassName, Name: item.Values[fieldName]}
+		sysIDs[i] = results[i].SysId
+		if results[i].Operation != operationNoChange {
+			changed++
+		}
+	}
+	r.log.Info("Registered CIs", "cis", len(cis), "changed", changed, "retired", len(gone), "duration", time.Since(start))
+
+	if changed > 0 {
+		r.record.Event(mg, event.Normal(reasonRegisteredCIs, fmt.Sprintf("Registered %d CIs, of which %d changed", len(cis), changed)))
+	} else if len(cis) > 0 {
+		r.record.Event(mg, event.Normal(reasonUnchangedCIs, fmt.Sprintf("%d CIs were already up to date", len(cis))))
+	}
+	if len(gone) > 0 {
+		r.record.Event(mg, event.Normal(reasonRetiredCIs, fmt.Sprintf("Retired %d CIs of deleted objects", len(gone))))
+	}
+	return cis, sysIDs, nil
+}
+
+// retire retires the supplied registered CIs.
+func (r *registrar) retire(ctx context.Context, mg resource.Managed, source string, registered []v1alpha1.InventoryCI) error {
+	items := make([]*models.IdentifyReconcileItem, len(registered))
+	for i, ci := range registered {
+		items[i] = retirement(ci.ClassName, ci.Name)
+	}
+
+	if r.readOnly {
+		r.log.Info("Not retiring CIs in dry run mode", "cis", len(items))
+		r.record.Event(mg, event.Normal(reasonPlannedChange, fmt.Sprintf(msgPlannedRetire, len(items))))
+		return nil
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	deferred, err := r.deferred(ctx, mg)
+	if err != nil {
+		return err
+	}
+	if deferred {
+		return errors.New(errDeferred)
+	}
+
+	if _, err := r.submit(ctx, source, items, nil); err != nil {
+		return errors.Wrap(err, errRetireFailed)
+	}
+	r.log.Info("Retired CIs", "cis", len(items))
+	r.record.Event(mg, event.Normal(reasonRetiredCIs, fmt.Sprintf("Retired %d CIs", len(items))))
+	return nil
+}
+
+// submit sends the supplied items, and the relations between them, to the
+// Identification and Reconciliation API in batches. It returns the result of
+// every item, in the order of the items.
+func (r *registrar) submit(ctx context.Context, source string, items []*models.IdentifyReconcileItem, relations []idenrecon.Relation) ([]models.Items, error) {
+	results := make([]models.Items, 0, len(items))
+	for _, b := range batches(items, relations) {
+		spanCtx, span := tracing.Start(ctx, "ServiceNow IRE CreateIdentifyReconcile", attribute.Int("servicenow.items", len(b.items)))
+		response, err := r.serviceIdenRecon.CreateIdentifyReconcile(idenrecon.GenerateItemsOptions(spanCtx, source, b.items), idenrecon.WithRelations(b.relations))
+		tracing.End(span, err)
+		if err != nil {
+			return nil, clients.Annotate(err)
+		}
+		var res []models.Items
+		if p := response.Payload; p != nil && p.Result != nil && p.Result.Items != nil {
+			res = *p.Result.Items
+		}
+		if len(res) < b.size {
+			return nil, errors.New(errShortResult)
+		}
+		results = append(results, res[:b.size]...)
+	}
+	return results, nil
+}
+
+// A batch of items sent to the Identification and Reconciliation API in one
+// request.
+type batch struct {
+	items     []*models.IdentifyReconcileItem
+	relations []idenrecon.Relation
+
+	// size is the number of items of the batch, not counting the parents
+	// of its items that belong to earlier batches.
+	size int
+}
+
+// batches splits the supplied items, and the relations between them, into
+// batches of at most maxBatchItems items. A relation can only refer to items
+// of its own request, so the parent of an item in an earlier batch is sent
+// again after the items of the batch. The Identification and Reconciliation
+// API identifies it as the CI it already registered.
+func batches(items []*models.IdentifyReconcileItem, relations []idenrecon.Relation) []batch {
+	var bs []batch
+	for start := 0; start < len(items); start += maxBatchItems {
+		end := start + maxBatchItems
+		if end > len(items) {
+			end = len(items)
+		}
+		b := batch{items: append([]*models.IdentifyReconcileItem{}, items[start:end]...), size: end - start}
+		parents := map[int]int{}
+		for _, rel := range relations {
+			if rel.Child < start || rel.Child >= end {
+				continue
+			}
+			parent := rel.Parent - start
+			if rel.Parent < start || rel.Parent >= end {
+				idx, ok := parents[rel.Parent]
+				if !ok {
+					idx = len(b.items)
+					b.items = append(b.items, items[rel.Parent])
+					parents[rel.Parent] = idx
+				}
+				parent = idx
+			}
+			b.relations = append(b.relations, idenrecon.Relation{Parent: parent, Child: rel.Child - start, Type: rel.Type})
+		}
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+// retired returns the registered CIs that are no longer in the supplied
+// inventory.
+func retired(registered []v1alpha1.InventoryCI, inv *inventory) []v1alpha1.InventoryCI {
+	var gone []v1alpha1.InventoryCI
+	for _, ci := range registered {
+		if !inv.contains(ci.ClassName, ci.Name) {
+			gone = append(gone, ci)
+		}
+	}
+	return gone
+}
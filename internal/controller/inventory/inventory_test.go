@@ -0,0 +1,102 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
+)
+
+// clusterOf returns the inventory of a cluster with the supplied number of
+// nodes.
+func clusterOf(nodes int) *inventory {
+	inv := &inventory{}
+	cluster := inv.add(classCluster, testCluster, nil, -1)
+	for i := 0; i < nodes; i++ {
+		inv.add(classNode, qualify(testCluster, fmt.Sprintf("node-%d", i)), nil, cluster)
+	}
+	return inv
+}
+
+func TestBatches(t *testing.T) {
+	type want struct {
+		items     []int
+		sizes     []int
+		relations []idenrecon.Relation
+	}
+
+	cases := map[string]struct {
+		reason string
+		inv    *inventory
+		want   want
+	}{
+		"OneBatch": {
+			reason: "An inventory that fits in one batch should be sent as is.",
+			inv:    clusterOf(2),
+			want: want{
+				items: []int{3},
+				sizes: []int{3},
+			},
+		},
+		"ParentInEarlierBatch": {
+			reason: "The parent of items in a later batch should be sent again with that batch.",
+			inv:    clusterOf(maxBatchItems + 1),
+			want: want{
+				items:     []int{maxBatchItems, 3},
+				sizes:     []int{maxBatchItems, 2},
+				relations: []idenrecon.Relation{{Parent: 2, Child: 0, Type: idenrecon.RelationContains}, {Parent: 2, Child: 1, Type: idenrecon.RelationContains}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			bs := batches(tc.inv.items, tc.inv.relations)
+			var items, sizes []int
+			for _, b := range bs {
+				items = append(items, len(b.items))
+				sizes = append(sizes, b.size)
+			}
+			if diff := cmp.Diff(tc.want.items, items); diff != "" {
+				t.Errorf("\n%s\nbatches(...): -want items, +got items:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.sizes, sizes); diff != "" {
+				t.Errorf("\n%s\nbatches(...): -want sizes, +got sizes:\n%s\n", tc.reason, diff)
+			}
+			if len(bs) < 2 {
+				return
+			}
+			last := bs[len(bs)-1]
+			if diff := cmp.Diff(tc.want.relations, last.relations); diff != "" {
+				t.Errorf("\n%s\nbatches(...): -want relations of the last batch, +got:\n%s\n", tc.reason, diff)
+			}
+			if got := last.items[len(last.items)-1].Values[fieldName]; got != testCluster {
+				t.Errorf("\n%s\nbatches(...): want the cluster to be sent again, got %q", tc.reason, got)
+			}
+		})
+	}
+}
+
+func TestRegisterTooManyCIs(t *testing.T) {
+	r := &registrar{log: logging.NewNopLogger(), record: event.NewNopRecorder()}
+	inv := clusterOf(maxInventoryCIs)
+
+	_, _, err := r.register(context.Background(), inventoryCR(), "ServiceNow", inv, []v1alpha1.InventoryCI{})
+	want := errors.Errorf("%s: %d CIs, at most %d", errTooManyCIs, maxInventoryCIs+1, maxInventoryCIs)
+	if diff := cmp.Diff(want, err, test.EquateErrors()); diff != "" {
+		t.Errorf("register(...): -want error, +got error:\n%s\n", diff)
+	}
+}
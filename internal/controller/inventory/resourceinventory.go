@@ -0,0 +1,357 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/connection"
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	sdkIdenRecon "github.com/anka-software/cmdb-sdk/pkg/client/cmdb"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
+	"github.com/crossplane/provider-cmdb/internal/clients/schedule"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+	"github.com/crossplane/provider-cmdb/internal/controller/features"
+	"github.com/crossplane/provider-cmdb/internal/controller/ratelimit"
+	"github.com/crossplane/provider-cmdb/internal/tracing"
+)
+
+const (
+	errNotResourceInventory = "managed resource is not a ResourceInventory custom resource"
+	errListResourceInvs     = "cannot list ResourceInventories"
+	errResourceSelector     = "cannot parse resource selector"
+	errListResources        = "cannot list"
+	errWatchResources       = "cannot watch"
+	errGrantResources       = "grant the provider get, list and watch on them, see examples/idenrecon/resourceinventory-rbac.yaml"
+	errGetResourceName      = "cannot get CI name of"
+	errGetResourceField     = "cannot get CI field"
+	errEncodeResourceField  = "cannot encode CI field"
+)
+
+const (
+	// defaultNameFieldPath is the path of the field of a managed resource
+	// the name of its CI is read from by default.
+	defaultNameFieldPath = "metadata.name"
+
+	// fieldCorrelationID of a CI cross-references the external name of its
+	// managed resource.
+	fieldCorrelationID = "correlation_id"
+)
+
+// setupResourceInventory adds a controller that reconciles ResourceInventory
+// managed resources. The kinds of managed resources they register are only
+// known at runtime, so they are watched once a ResourceInventory has listed
+// them.
+func setupResourceInventory(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.ResourceInventoryGroupKind)
+
+	cps := []managed.ConnectionPublisher{managed.NewAPISecretPublisher(mgr.GetClient(), mgr.GetScheme())}
+	if o.Features.Enabled(features.EnableAlphaExternalSecretStores) {
+		cps = append(cps, connection.NewDetailsManager(mgr.GetClient(), apisv1alpha1.StoreConfigGroupVersionKind))
+	}
+
+	log := o.Logger.WithValues("controller", name)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	w := &resourceWatcher{kube: mgr.GetClient(), log: log, watched: map[schema.GroupVersionKind]bool{}}
+
+	r := managed.NewReconciler(mgr,
+		resource.ManagedKind(v1alpha1.ResourceInventoryGroupVersionKind),
+		managed.WithExternalConnecter(&resourceConnector{
+			kube:                  mgr.GetClient(),
+			usage:                 resource.NewProviderConfigUsageTracker(mgr.GetClient(), &apisv1alpha1.ProviderConfigUsage{}),
+			newServiceFnIdenRecon: idenrecon.NewIdenReconClient,
+			log:                   log,
+			record:                recorder,
+			watcher:               w,
+			dryRun:                o.Features.Enabled(features.DryRun),
+		}),
+		// The external name is the class of the CIs, set once they are
+		// registered.
+		managed.WithInitializers(),
+		managed.WithLogger(log),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithRecorder(recorder),
+		managed.WithConnectionPublishers(cps...))
+
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		For(&v1alpha1.ResourceInventory{}).
+		Build(ratelimiter.NewReconciler(name, tracing.NewReconciler(name, ratelimit.NewReconciler(mgr.GetClient(), resource.ManagedKind(v1alpha1.ResourceInventoryGroupVersionKind), r, log)), o.GlobalRateLimiter))
+	if err != nil {
+		return err
+	}
+	w.controller = c
+	return nil
+}
+
+// A resourceWatcher watches the kinds of managed resources registered by
+// ResourceInventories.
+type resourceWatcher struct {
+	controller crcontroller.Controller
+	kube       client.Client
+	log        logging.Logger
+
+	mu      sync.Mutex
+	watched map[schema.GroupVersionKind]bool
+}
+
+// watch starts watching the supplied kinds of managed resources, unless they
+// are already watched. Changes to their spec, labels and annotations requeue
+// the ResourceInventories that register them. Changes to their status are
+// picked up when they are polled.
+func (w *resourceWatcher) watch(kinds []v1alpha1.ResourceKind) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, k := range kinds {
+		gvk := schema.FromAPIVersionAndKind(k.APIVersion, k.Kind)
+		if w.watched[gvk] {
+			continue
+		}
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		p := predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{})
+		if err := w.controller.Watch(&source.Kind{Type: u}, handler.EnqueueRequestsFromMapFunc(resourceInventories(w.kube, gvk, w.log)), p); err != nil {
+			return errors.Wrapf(err, "%s %s", errWatchResources, k.Kind)
+		}
+		w.watched[gvk] = true
+		w.log.Debug("Watching managed resources", "apiVersion", k.APIVersion, "kind", k.Kind)
+	}
+	return nil
+}
+
+// resourceInventories returns a function that maps a managed resource of the
+// supplied kind to requests for every ResourceInventory that registers it.
+func resourceInventories(kube client.Client, gvk schema.GroupVersionKind, log logging.Logger) handler.MapFunc {
+	return func(_ client.Object) []reconcile.Request {
+		l := &v1alpha1.ResourceInventoryList{}
+		if err := kube.List(context.Background(), l); err != nil {
+			log.Info(errListResourceInvs, "error", err)
+			return nil
+		}
+		var reqs []reconcile.Request
+		for i := range l.Items {
+			for _, k := range l.Items[i].Spec.ForProvider.Resources {
+				if schema.FromAPIVersionAndKind(k.APIVersion, k.Kind) == gvk {
+					reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&l.Items[i])})
+					break
+				}
+			}
+		}
+		return reqs
+	}
+}
+
+// A resourceConnector is expected to produce an ExternalClient when its
+// Connect method is called.
+type resourceConnector struct {
+	kube                  client.Client
+	usage                 resource.Tracker
+	newServiceFnIdenRecon func(cfg clients.Config) sdkIdenRecon.ClientService
+	log                   logging.Logger
+	record                event.Recorder
+	watcher               *resourceWatcher
+	dryRun                bool
+}
+
+// Connect produces an ExternalClient for the ProviderConfig of the supplied
+// ResourceInventory.
+func (c *resourceConnector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	cr, ok := mg.(*v1alpha1.ResourceInventory)
+	if !ok {
+		return nil, errors.New(errNotResourceInventory)
+	}
+
+	if err := c.usage.Track(ctx, mg); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	cfg, err := clients.GetConfig(ctx, c.kube, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	log := c.log.WithValues("resource", cr.GetName(), "class", cr.Spec.ForProvider.ClassName)
+
+	return &resourceExternal{kube: c.kube, watcher: c.watcher, registrar: registrar{serviceIdenRecon: c.newServiceFnIdenRecon(*cfg), log: log, record: c.record, readOnly: c.dryRun || cfg.ReadOnly, gate: schedule.NewGate(*cfg, table.NewTableClient(*cfg))}}, nil
+}
+
+// A resourceExternal registers managed resources as CIs.
+type resourceExternal struct {
+	registrar
+	kube    client.Client
+	watcher *resourceWatcher
+}
+
+func (c *resourceExternal) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.ResourceInventory)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotResourceInventory)
+	}
+	return c.observe(ctx, cr, c.collector(cr))
+}
+
+func (c *resourceExternal) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.ResourceInventory)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotResourceInventory)
+	}
+	return c.create(ctx, cr, c.collector(cr))
+}
+
+func (c *resourceExternal) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.ResourceInventory)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotResourceInventory)
+	}
+	return c.update(ctx, cr, c.collector(cr))
+}
+
+func (c *resourceExternal) Delete(ctx context.Context, mg resource.Managed) error {
+	cr, ok := mg.(*v1alpha1.ResourceInventory)
+	if !ok {
+		return errors.New(errNotResourceInventory)
+	}
+	return c.delete(ctx, cr)
+}
+
+// collector returns the collector of the managed resources selected by the
+// supplied ResourceInventory.
+func (c *resourceExternal) collector(cr *v1alpha1.ResourceInventory) collector {
+	return &resourceCollector{kube: c.kube, watcher: c.watcher, params: &cr.Spec.ForProvider}
+}
+
+// A resourceCollector collects the selected managed resources, and watches
+// their kinds.
+type resourceCollector struct {
+	kube    client.Client
+	watcher *resourceWatcher
+	params  *v1alpha1.ResourceInventoryParameters
+}
+
+func (c *resourceCollector) collect(ctx context.Context) (*inventory, error) {
+	inv, err := collectResources(ctx, c.kube, c.params)
+	if err != nil {
+		return nil, err
+	}
+	// Kinds are only watched once they could be listed, so that a kind the
+	// provider may not list is not retried by an informer forever.
+	if err := c.watcher.watch(c.params.Resources); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// externalName returns the class of the CIs. There may be no managed
+// resources to register yet, so it is not the sys_id of any of them.
+func (c *resourceCollector) externalName(_ []string) string {
+	return c.params.ClassName
+}
+
+// collectResources returns the inventory of the selected managed resources, in
+// a stable order.
+func collectResources(ctx context.Context, kube client.Client, p *v1alpha1.ResourceInventoryParameters) (*inventory, error) {
+	sel := labels.Everything()
+	if p.Selector != nil {
+		s, err := metav1.LabelSelectorAsSelector(p.Selector)
+		if err != nil {
+			return nil, errors.Wrap(err, errResourceSelector)
+		}
+		sel = s
+	}
+
+	inv := &inventory{}
+	for _, k := range p.Resources {
+		l := &unstructured.UnstructuredList{}
+		l.SetAPIVersion(k.APIVersion)
+		l.SetKind(k.Kind + "List")
+		if err := kube.List(ctx, l, client.MatchingLabelsSelector{Selector: sel}); err != nil {
+			if kerrors.IsForbidden(err) {
+				return nil, errors.Wrapf(err, "%s %s, %s", errListResources, k.Kind, errGrantResources)
+			}
+			return nil, errors.Wrapf(err, "%s %s", errListResources, k.Kind)
+		}
+		sort.Slice(l.Items, func(i, j int) bool { return l.Items[i].GetName() < l.Items[j].GetName() })
+		for i := range l.Items {
+			u := &l.Items[i]
+			name, values, err := resourceValues(u, p)
+			if err != nil {
+				return nil, err
+			}
+			inv.add(p.ClassName, name, values, -1)
+		}
+	}
+	return inv, nil
+}
+
+// resourceValues returns the name and values of the CI of the supplied managed
+// resource.
+func resourceValues(u *unstructured.Unstructured, p *v1alpha1.ResourceInventoryParameters) (string, map[string]string, error) {
+	paved := fieldpath.Pave(u.Object)
+
+	path := defaultNameFieldPath
+	if p.NameFieldPath != nil {
+		path = *p.NameFieldPath
+	}
+	name, err := paved.GetString(path)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "%s %s %s", errGetResourceName, u.GetKind(), u.GetName())
+	}
+
+	values := map[string]string{fieldCorrelationID: meta.GetExternalName(u)}
+	for _, f := range p.Fields {
+		v, err := paved.GetValue(f.FromFieldPath)
+		if fieldpath.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "%s %s", errGetResourceField, f.Field)
+		}
+		switch s := v.(type) {
+		case nil:
+		case string:
+			values[f.Field] = s
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", nil, errors.Wrapf(err, "%s %s", errEncodeResourceField, f.Field)
+			}
+			values[f.Field] = string(b)
+		}
+	}
+	return name, values, nil
+}
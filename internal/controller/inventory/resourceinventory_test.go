@@ -0,0 +1,273 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package inventory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+const classDBInstance = "cmdb_ci_db_instance"
+
+func database(name, externalName string, labels map[string]string, atProvider map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"forProvider": map[string]interface{}{"engineVersion": "13.4"}},
+	}}
+	if atProvider != nil {
+		u.Object["status"] = map[string]interface{}{"atProvider": atProvider}
+	}
+	u.SetAPIVersion("database.aws.crossplane.io/v1beta1")
+	u.SetKind("RDSInstance")
+	u.SetName(name)
+	u.SetLabels(labels)
+	meta.SetExternalName(u, externalName)
+	return u
+}
+
+func resourceInventoryCR() *v1alpha1.ResourceInventory {
+	return &v1alpha1.ResourceInventory{
+		ObjectMeta: metav1.ObjectMeta{Name: "databases"},
+		Spec: v1alpha1.ResourceInventorySpec{ForProvider: v1alpha1.ResourceInventoryParameters{
+			SysParamDataSource: "ServiceNow",
+			Resources:          []v1alpha1.ResourceKind{{APIVersion: "database.aws.crossplane.io/v1beta1", Kind: "RDSInstance"}},
+			Selector:           &metav1.LabelSelector{MatchLabels: map[string]string{"cmdb": "true"}},
+			ClassName:          classDBInstance,
+			Fields: []v1alpha1.FieldMapping{
+				{Field: "version", FromFieldPath: "spec.forProvider.engineVersion"},
+				{Field: "ip_address", FromFieldPath: "status.atProvider.endpoint.address"},
+				{Field: "tcp_port", FromFieldPath: "status.atProvider.endpoint.port"},
+			},
+		}},
+	}
+}
+
+func TestResourceValues(t *testing.T) {
+	nameFieldPath := "spec.forProvider.dbName"
+
+	type want struct {
+		name   string
+		values map[string]string
+		err    error
+	}
+
+	cases := map[string]struct {
+		reason string
+		u      *unstructured.Unstructured
+		p      *v1alpha1.ResourceInventoryParameters
+		want   want
+	}{
+		"MappedFields": {
+			reason: "Mapped fields should be set, encoding values that are not strings as JSON.",
+			u:      database("orders", "orders-db", nil, map[string]interface{}{"endpoint": map[string]interface{}{"address": "orders.rds.amazonaws.com", "port": int64(5432)}}),
+			p:      &resourceInventoryCR().Spec.ForProvider,
+			want: want{name: "orders", values: map[string]string{
+				fieldCorrelationID: "orders-db",
+				"version":          "13.4",
+				"ip_address":       "orders.rds.amazonaws.com",
+				"tcp_port":         "5432",
+			}},
+		},
+		"MissingFields": {
+			reason: "Fields the managed resource does not have yet should not be set.",
+			u:      database("orders", "orders-db", nil, nil),
+			p:      &resourceInventoryCR().Spec.ForProvider,
+			want:   want{name: "orders", values: map[string]string{fieldCorrelationID: "orders-db", "version": "13.4"}},
+		},
+		"MissingName": {
+			reason: "A managed resource without a CI name should return an error.",
+			u:      database("orders", "orders-db", nil, nil),
+			p:      &v1alpha1.ResourceInventoryParameters{NameFieldPath: &nameFieldPath},
+			want:   want{err: errors.Wrapf(errors.New("spec.forProvider.dbName: no such field"), "%s %s %s", errGetResourceName, "RDSInstance", "orders")},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			n, v, err := resourceValues(tc.u, tc.p)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nresourceValues(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.name, n); diff != "" {
+				t.Errorf("\n%s\nresourceValues(...): -want name, +got name:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.values, v); diff != "" {
+				t.Errorf("\n%s\nresourceValues(...): -want values, +got values:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+// TestResourceLifecycle registers the selected managed resources with a fake
+// ServiceNow instance, retires the CI of a deleted managed resource, then
+// retires every CI.
+func TestResourceLifecycle(t *testing.T) {
+	sn := servicenow.NewServer()
+	defer sn.Close()
+
+	kube := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(
+		database("orders", "orders-db", map[string]string{"cmdb": "true"}, map[string]interface{}{"endpoint": map[string]interface{}{"address": "orders.rds.amazonaws.com"}}),
+		database("users", "users-db", map[string]string{"cmdb": "true"}, nil),
+		database("scratch", "scratch-db", nil, nil),
+	).Build()
+	ctx := context.Background()
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+
+	newExternal := func() *resourceExternal {
+		return &resourceExternal{kube: kube, registrar: registrar{serviceIdenRecon: idenrecon.NewIdenReconClient(cfg), log: logging.NewNopLogger(), record: event.NewNopRecorder()}}
+	}
+	cr := resourceInventoryCR()
+
+	e := newExternal()
+	o, err := e.Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if diff := cmp.Diff(managed.ExternalObservation{}, o); diff != "" {
+		t.Errorf("Observe(...): unregistered inventory: -want, +got:\n%s\n", diff)
+	}
+	if _, err := e.Create(ctx, cr); err != nil {
+		t.Fatalf("Create(...): %v", err)
+	}
+
+	type ci struct{ Name, CorrelationID, IPAddress string }
+	var got []ci
+	for _, c := range cr.Status.AtProvider.CIs {
+		r := ciRecord(sn, c.ClassName, c.Name)
+		got = append(got, ci{Name: c.Name, CorrelationID: r[fieldCorrelationID], IPAddress: r["ip_address"]})
+	}
+	want := []ci{
+		{Name: "orders", CorrelationID: "orders-db", IPAddress: "orders.rds.amazonaws.com"},
+		{Name: "users", CorrelationID: "users-db"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Create(...): -want CIs, +got CIs:\n%s\n", diff)
+	}
+
+	o, err = newExternal().Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if diff := cmp.Diff(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, o); diff != "" {
+		t.Errorf("Observe(...): registered inventory: -want, +got:\n%s\n", diff)
+	}
+
+	users := cr.Status.AtProvider.CIs[1]
+	if err := kube.Delete(ctx, database("users", "users-db", nil, nil)); err != nil {
+		t.Fatalf("kube.Delete(...): %v", err)
+	}
+
+	e = newExternal()
+	o, err = e.Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if o.ResourceUpToDate {
+		t.Errorf("Observe(...): want an inventory with a deleted managed resource not to be up to date")
+	}
+	if _, err := e.Update(ctx, cr); err != nil {
+		t.Fatalf("Update(...): %v", err)
+	}
+	if r := ciRecord(sn, users.ClassName, users.Name); r[fieldInstallStatus] != installStatusRetired {
+		t.Errorf("Update(...): want the CI of the deleted managed resource to be retired, got %v", r)
+	}
+
+	cis := cr.Status.AtProvider.CIs
+	now := metav1.Now()
+	cr.SetDeletionTimestamp(&now)
+	if err := newExternal().Delete(ctx, cr); err != nil {
+		t.Fatalf("Delete(...): %v", err)
+	}
+	for _, c := range cis {
+		if r := ciRecord(sn, c.ClassName, c.Name); r[fieldInstallStatus] != installStatusRetired {
+			t.Errorf("Delete(...): want CI %s to be retired, got %v", c.Name, r)
+		}
+	}
+}
+
+// watchController records the kinds it is asked to watch.
+type watchController struct {
+	crcontroller.Controller
+	kinds []string
+}
+
+func (c *watchController) Watch(src source.Source, _ handler.EventHandler, _ ...predicate.Predicate) error {
+	c.kinds = append(c.kinds, src.(*source.Kind).Type.GetObjectKind().GroupVersionKind().Kind)
+	return nil
+}
+
+func TestResourceObserveWatch(t *testing.T) {
+	errBoom := errors.New("boom")
+	forbidden := kerrors.NewForbidden(schema.GroupResource{Group: "database.aws.crossplane.io", Resource: "rdsinstances"}, "", errBoom)
+
+	type want struct {
+		err   error
+		kinds []string
+	}
+	cases := map[string]struct {
+		reason string
+		kube   client.Client
+		want   want
+	}{
+		"Forbidden": {
+			reason: "Managed resources the provider may not list should be reported with a hint to grant RBAC, and not be watched.",
+			kube:   &test.MockClient{MockList: test.NewMockListFn(forbidden)},
+			want: want{
+				err: errors.Wrapf(forbidden, "%s %s, %s", errListResources, "RDSInstance", errGrantResources),
+			},
+		},
+		"Watched": {
+			reason: "Managed resources the provider could list should be watched once.",
+			kube:   fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build(),
+			want: want{
+				kinds: []string{"RDSInstance"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := &watchController{}
+			w := &resourceWatcher{controller: c, kube: tc.kube, log: logging.NewNopLogger(), watched: map[schema.GroupVersionKind]bool{}}
+			cr := resourceInventoryCR()
+
+			var err error
+			for i := 0; i < 2 && err == nil; i++ {
+				e := &resourceExternal{kube: tc.kube, watcher: w}
+				_, err = e.Observe(context.Background(), cr)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nObserve(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.kinds, c.kinds); diff != "" {
+				t.Errorf("\n%s\nObserve(...): -want watched kinds, +got watched kinds:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
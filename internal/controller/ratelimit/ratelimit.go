@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ratelimit holds back managed resources whose ServiceNow instance
+// has asked not to receive requests for a while, and requeues those whose
+// changes were deferred until their write window opens.
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/schedule"
+)
+
+const (
+	errGetManaged = "cannot get managed resource"
+)
+
+// A Reconciler requeues requests for managed resources whose ServiceNow
+// instance responded with 429 Too Many Requests, or is unavailable, until
+// the instance allows requests again. Other requests are passed to the
+// wrapped Reconciler, and requeued by the time the write window of their
+// ProviderConfig opens if changes were deferred until then.
+type Reconciler struct {
+	kube       client.Client
+	inner      reconcile.Reconciler
+	newManaged func() resource.Managed
+	log        logging.Logger
+}
+
+// NewReconciler wraps the supplied Reconciler of the supplied managed
+// resource kind.
+func NewReconciler(m client.Client, of resource.ManagedKind, r reconcile.Reconciler, l logging.Logger) *Reconciler {
+	nm := func() resource.Managed {
+		return resource.MustCreateObject(schema.GroupVersionKind(of), m.Scheme()).(resource.Managed)
+	}
+
+	// Panic early if we've been asked to reconcile a resource kind that has
+	// not been registered with our controller manager's scheme.
+	_ = nm()
+
+	return &Reconciler{kube: m, inner: r, newManaged: nm, log: l}
+}
+
+// Reconcile the supplied request unless its ServiceNow instance is rate
+// limited or unavailable.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	mg := r.newManaged()
+	if err := r.kube.Get(ctx, req.NamespacedName, mg); err != nil {
+		return reconcile.Result{}, errors.Wrap(resource.IgnoreNotFound(err), errGetManaged)
+	}
+
+	ref := mg.GetProviderConfigReference()
+	if ref != nil {
+		if d := clients.RetryAfter(ref.Name); d > 0 {
+			r.log.Debug("ServiceNow instance is not accepting requests", "request", req, "providerConfig", ref.Name, "retryAfter", d)
+			return reconcile.Result{RequeueAfter: d}, nil
+		}
+	}
+
+	res, err := r.inner.Reconcile(ctx, req)
+	if ref == nil || err != nil || res.Requeue {
+		return res, err
+	}
+	if d := schedule.OpensIn(ref.Name); d > 0 && (res.RequeueAfter == 0 || d < res.RequeueAfter) {
+		res.RequeueAfter = d
+	}
+	return res, nil
+}
@@ -0,0 +1,290 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package table
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/connection"
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	sdkTable "github.com/anka-software/cmdb-sdk/pkg/client/table"
+
+	"github.com/crossplane/provider-cmdb/apis/table/v1alpha1"
+	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+	"github.com/crossplane/provider-cmdb/internal/controller/features"
+	"github.com/crossplane/provider-cmdb/internal/controller/ratelimit"
+	"github.com/crossplane/provider-cmdb/internal/tracing"
+)
+
+const (
+	errNotTable      = "managed resource is not a Table custom resource"
+	errQueryFailed   = "cannot query records with Table API"
+	errQueryConflict = "query and structuredQuery are mutually exclusive"
+	errInvalidQuery  = "invalid structuredQuery"
+
+	msgNoRecords = "No records match the query"
+)
+
+const (
+	// defaultLimit and maxLimit are the number of records a Table reads by
+	// default and at most, so that a broad query does not read a whole
+	// table on every poll.
+	defaultLimit = 100
+	maxLimit     = 1000
+
+	// maxStatusRecords is the number of records whose fields are reported
+	// in the status of a Table.
+	maxStatusRecords = 10
+)
+
+// Keys of the connection details of a Table.
+const (
+	keyCount = "count"
+	keySysID = "sys_id"
+)
+
+// setupTable adds a controller that reconciles Table managed resources.
+func setupTable(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.TableGroupKind)
+
+	cps := []managed.ConnectionPublisher{managed.NewAPISecretPublisher(mgr.GetClient(), mgr.GetScheme())}
+	if o.Features.Enabled(features.EnableAlphaExternalSecretStores) {
+		cps = append(cps, connection.NewDetailsManager(mgr.GetClient(), apisv1alpha1.StoreConfigGroupVersionKind))
+	}
+
+	log := o.Logger.WithValues("controller", name)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+
+	r := managed.NewReconciler(mgr,
+		resource.ManagedKind(v1alpha1.TableGroupVersionKind),
+		managed.WithExternalConnecter(&connector{
+			kube:              mgr.GetClient(),
+			usage:             resource.NewProviderConfigUsageTracker(mgr.GetClient(), &apisv1alpha1.ProviderConfigUsage{}),
+			newServiceFnTable: table.NewTableClient,
+			log:               log,
+		}),
+		// A Table only reads records, so it has no external name.
+		managed.WithInitializers(),
+		managed.WithLogger(log),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithRecorder(recorder),
+		managed.WithConnectionPublishers(cps...))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		For(&v1alpha1.Table{}).
+		Complete(ratelimiter.NewReconciler(name, tracing.NewReconciler(name, ratelimit.NewReconciler(mgr.GetClient(), resource.ManagedKind(v1alpha1.TableGroupVersionKind), r, log)), o.GlobalRateLimiter))
+}
+
+// A connector is expected to produce an ExternalClient when its Connect method
+// is called.
+type connector struct {
+	kube              client.Client
+	usage             resource.Tracker
+	newServiceFnTable func(cfg clients.Config) sdkTable.ClientService
+	log               logging.Logger
+}
+
+// Connect produces an ExternalClient for the ProviderConfig of the supplied
+// Table.
+func (c *connector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	cr, ok := mg.(*v1alpha1.Table)
+	if !ok {
+		return nil, errors.New(errNotTable)
+	}
+
+	if err := c.usage.Track(ctx, mg); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	cfg, err := clients.GetConfig(ctx, c.kube, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	log := c.log.WithValues("resource", cr.GetName(), "table", cr.Spec.ForProvider.TableName)
+
+	return &external{serviceTable: c.newServiceFnTable(*cfg), log: log}, nil
+}
+
+// An external runs the query of a Table. It never changes ServiceNow, so a
+// Table always exists and is up to date.
+type external struct {
+	serviceTable sdkTable.ClientService
+	log          logging.Logger
+}
+
+func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.Table)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotTable)
+	}
+
+	if meta.WasDeleted(cr) {
+		return managed.ExternalObservation{}, nil
+	}
+
+	p := &cr.Spec.ForProvider
+	query, err := encodedQuery(p)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+
+	start := time.Now()
+	spanCtx, span := tracing.Start(ctx, "ServiceNow Table GetTableItems", attribute.String("servicenow.table", p.TableName))
+	results, err := table.ReadAll(c.serviceTable, table.GenerateQueryOptions(spanCtx, p.TableName, query), readOptions(p))
+	tracing.End(span, err)
+	if e, ok := clients.AsUnavailable(err); ok {
+		cr.SetConditions(e.Condition())
+	}
+	if err != nil {
+		return managed.ExternalObservation{}, errors.Wrap(clients.Annotate(err), errQueryFailed)
+	}
+	c.log.Debug("Queried records with Table API", "records", len(results), "duration", time.Since(start))
+
+	cr.Status.AtProvider = observation(p, results)
+
+	if len(results) == 0 {
+		cr.SetConditions(xpv1.Unavailable().WithMessage(msgNoRecords))
+	} else {
+		cr.SetConditions(xpv1.Available())
+	}
+
+	return managed.ExternalObservation{
+		ResourceExists:    true,
+		ResourceUpToDate:  true,
+		ConnectionDetails: connectionDetails(p, cr.Status.AtProvider),
+	}, nil
+}
+
+func (c *external) Create(_ context.Context, _ resource.Managed) (managed.ExternalCreation, error) {
+	return managed.ExternalCreation{}, nil
+}
+
+func (c *external) Update(_ context.Context, _ resource.Managed) (managed.ExternalUpdate, error) {
+	return managed.ExternalUpdate{}, nil
+}
+
+func (c *external) Delete(_ context.Context, _ resource.Managed) error {
+	return nil
+}
+
+// encodedQuery returns the encoded query of the supplied Table. ReadAll orders
+// its records by sys_id unless the query orders them, so that the first record
+// is always the same.
+func encodedQuery(p *v1alpha1.TableParameters) (string, error) {
+	sq := p.StructuredQuery
+	switch {
+	case sq == nil:
+		return p.Query, nil
+	case p.Query != "":
+		return "", errors.New(errQueryConflict)
+	}
+
+	q := table.NewQuery()
+	for _, c := range sq.Conditions {
+		q.And(condition(c.QueryTerm))
+		for _, t := range c.Or {
+			q.Or(condition(t))
+		}
+	}
+	for _, o := range sq.OrderBy {
+		if o.Descending {
+			q.OrderByDesc(o.Field)
+			continue
+		}
+		q.OrderBy(o.Field)
+	}
+	if err := q.Validate(); err != nil {
+		return "", errors.Wrap(err, errInvalidQuery)
+	}
+	return q.String(), nil
+}
+
+// condition returns the query condition of the supplied term.
+func condition(t v1alpha1.QueryTerm) table.Condition {
+	c := table.Condition{Field: t.Field, Operator: table.OpEquals, Values: t.Values}
+	if t.Operator != "" {
+		c.Operator = table.Operator(t.Operator)
+	}
+	if t.Value != nil {
+		c.Values = append([]string{*t.Value}, t.Values...)
+	}
+	return c
+}
+
+// readOptions returns the options the query of the supplied Table is read
+// with. Only the sys_ids and selected fields of up to the limit of records
+// are read.
+func readOptions(p *v1alpha1.TableParameters) table.ReadOptions {
+	o := table.ReadOptions{
+		Fields:               append([]string{fieldSysID}, p.Fields...),
+		DisplayValue:         p.DisplayValue,
+		ExcludeReferenceLink: true,
+		Limit:                defaultLimit,
+	}
+	if p.Limit != nil {
+		o.Limit = *p.Limit
+	}
+	if o.Limit > maxLimit {
+		o.Limit = maxLimit
+	}
+	return o
+}
+
+// observation returns the observation of the supplied records. The fields of
+// only the first records are reported.
+func observation(p *v1alpha1.TableParameters, results []map[string]interface{}) v1alpha1.TableObservation {
+	o := v1alpha1.TableObservation{Count: len(results)}
+	for i, r := range results {
+		o.SysIDs = append(o.SysIDs, table.FieldValue(r[fieldSysID]))
+		if len(p.Fields) == 0 || i >= maxStatusRecords {
+			continue
+		}
+		values := make(map[string]string, len(p.Fields))
+		for _, f := range p.Fields {
+			if v, ok := r[f]; ok {
+				values[f] = table.FieldValue(v)
+			}
+		}
+		o.Records = append(o.Records, values)
+	}
+	return o
+}
+
+// connectionDetails returns the count of the records, and the sys_id and
+// selected fields of the first record.
+func connectionDetails(p *v1alpha1.TableParameters, o v1alpha1.TableObservation) managed.ConnectionDetails {
+	cd := managed.ConnectionDetails{keyCount: []byte(strconv.Itoa(o.Count))}
+	if len(o.SysIDs) > 0 {
+		cd[keySysID] = []byte(o.SysIDs[0])
+	}
+	if len(o.Records) > 0 {
+		for _, f := range p.Fields {
+			if v, ok := o.Records[0][f]; ok {
+				cd[f] = []byte(v)
+			}
+		}
+	}
+	return cd
+}
@@ -0,0 +1,224 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package table
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	sdkTable "github.com/anka-software/cmdb-sdk/pkg/client/table"
+	"github.com/anka-software/cmdb-sdk/pkg/models"
+
+	"github.com/crossplane/provider-cmdb/apis/table/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/fake"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+func query(fields ...string) *v1alpha1.Table {
+	return &v1alpha1.Table{
+		ObjectMeta: metav1.ObjectMeta{Name: "istanbul"},
+		Spec: v1alpha1.TableSpec{ForProvider: v1alpha1.TableParameters{
+			TableName: tableChoice,
+			Query:     "element=location^labelSTARTSWITHIstanbul",
+			Fields:    fields,
+		}},
+	}
+}
+
+func TestTableObserve(t *testing.T) {
+	sn := servicenow.NewServer(servicenow.WithTable(tableChoice))
+	defer sn.Close()
+
+	first := sn.Insert(tableChoice, servicenow.Record{"element": "location", "label": "Istanbul Kadikoy", "value": "ist-1"})
+	second := sn.Insert(tableChoice, servicenow.Record{"element": "location", "label": "Istanbul Levent"})
+	sn.Insert(tableChoice, servicenow.Record{"element": "location", "label": "Ankara", "value": "ank-1"})
+
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+	errBoom := errors.New("boom")
+	many := make([]map[string]interface{}, maxStatusRecords+5)
+	for i := range many {
+		many[i] = map[string]interface{}{fieldSysID: strconv.Itoa(i), "label": strconv.Itoa(i)}
+	}
+
+	type want struct {
+		o          managed.ExternalObservation
+		atProvider v1alpha1.TableObservation
+		ready      xpv1.Condition
+		err        error
+	}
+
+	cases := map[string]struct {
+		reason string
+		client sdkTable.ClientService
+		mg     resource.Managed
+		want   want
+	}{
+		"Records": {
+			reason: "The sys_ids and selected fields of the matching records should be reported.",
+			client: table.NewTableClient(cfg),
+			mg:     query("label", "value"),
+			want: want{
+				o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: managed.ConnectionDetails{
+					keyCount: []byte("2"), keySysID: []byte(first), "label": []byte("Istanbul Kadikoy"), "value": []byte("ist-1"),
+				}},
+				atProvider: v1alpha1.TableObservation{
+					Count:   2,
+					SysIDs:  []string{first, second},
+					Records: []map[string]string{{"label": "Istanbul Kadikoy", "value": "ist-1"}, {"label": "Istanbul Levent"}},
+				},
+				ready: xpv1.Available(),
+			},
+		},
+		"TooManyRecords": {
+			reason: "The fields of only the first records should be reported in the status.",
+			client: &fake.MockTableClient{MockGetTableItems: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+				return &sdkTable.GetTableItemsOK{Payload: &models.GetTableItem{Result: many}}, nil
+			}},
+			mg: query("label"),
+			want: want{
+				o: managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: managed.ConnectionDetails{
+					keyCount: []byte(strconv.Itoa(len(many))), keySysID: []byte("0"), "label": []byte("0"),
+				}},
+				atProvider: func() v1alpha1.TableObservation {
+					o := v1alpha1.TableObservation{Count: len(many)}
+					for i := range many {
+						o.SysIDs = append(o.SysIDs, strconv.Itoa(i))
+					}
+					for i := 0; i < maxStatusRecords; i++ {
+						o.Records = append(o.Records, map[string]string{"label": strconv.Itoa(i)})
+					}
+					return o
+				}(),
+				ready: xpv1.Available(),
+			},
+		},
+		"NoRecords": {
+			reason: "A query no records match should not be ready.",
+			client: &fake.MockTableClient{MockGetTableItems: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+				return &sdkTable.GetTableItemsOK{Payload: &models.GetTableItem{}}, nil
+			}},
+			mg: query("label"),
+			want: want{
+				o:     managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true, ConnectionDetails: managed.ConnectionDetails{keyCount: []byte("0")}},
+				ready: xpv1.Unavailable().WithMessage(msgNoRecords),
+			},
+		},
+		"QueryFailed": {
+			reason: "Errors querying the table should be returned.",
+			client: &fake.MockTableClient{MockGetTableItems: func(_ *sdkTable.GetTableItemsParams) (*sdkTable.GetTableItemsOK, error) {
+				return nil, errBoom
+			}},
+			mg:   query(),
+			want: want{err: errors.Wrap(errBoom, errQueryFailed)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := external{serviceTable: tc.client, log: logging.NewNopLogger()}
+			got, err := e.Observe(context.Background(), tc.mg)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			cr := tc.mg.(*v1alpha1.Table)
+			if diff := cmp.Diff(tc.want.atProvider, cr.Status.AtProvider); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want atProvider, +got atProvider:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ready, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want ready condition, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestEncodedQuery(t *testing.T) {
+	istanbul := "Istanbul^NQ"
+
+	type want struct {
+		query string
+		err   error
+	}
+
+	cases := map[string]struct {
+		reason string
+		p      *v1alpha1.TableParameters
+		want   want
+	}{
+		"Encoded": {
+			reason: "An encoded query should be used as is.",
+			p:      &v1alpha1.TableParameters{Query: "name=Istanbul^ORDERBYDESCsys_created_on"},
+			want:   want{query: "name=Istanbul^ORDERBYDESCsys_created_on"},
+		},
+		"Empty": {
+			reason: "A Table without a query should read every record.",
+			p:      &v1alpha1.TableParameters{},
+			want:   want{query: ""},
+		},
+		"StructuredUnordered": {
+			reason: "A structured query without an order should be encoded without one, and be ordered by ReadAll.",
+			p: &v1alpha1.TableParameters{StructuredQuery: &v1alpha1.StructuredQuery{
+				Conditions: []v1alpha1.QueryCondition{{QueryTerm: v1alpha1.QueryTerm{Field: "parent", Operator: "ISEMPTY"}}},
+			}},
+			want: want{query: "parentISEMPTY"},
+		},
+		"Structured": {
+			reason: "A structured query should be encoded, with its values escaped.",
+			p: &v1alpha1.TableParameters{StructuredQuery: &v1alpha1.StructuredQuery{
+				Conditions: []v1alpha1.QueryCondition{
+					{
+						QueryTerm: v1alpha1.QueryTerm{Field: "name", Value: &istanbul},
+						Or:        []v1alpha1.QueryTerm{{Field: "city", Operator: "IN", Values: []string{"Istanbul", "Ankara"}}},
+					},
+					{QueryTerm: v1alpha1.QueryTerm{Field: "parent", Operator: "ISEMPTY"}},
+				},
+				OrderBy: []v1alpha1.QueryOrder{{Field: "name"}, {Field: "sys_created_on", Descending: true}},
+			}},
+			want: want{query: "name=Istanbul^^NQ^ORcityINIstanbul,Ankara^parentISEMPTY^ORDERBYname^ORDERBYDESCsys_created_on"},
+		},
+		"Conflict": {
+			reason: "An encoded and a structured query should not be combined.",
+			p:      &v1alpha1.TableParameters{Query: "name=Istanbul", StructuredQuery: &v1alpha1.StructuredQuery{}},
+			want:   want{err: errors.New(errQueryConflict)},
+		},
+		"Invalid": {
+			reason: "A structured query that cannot be encoded should be rejected.",
+			p: &v1alpha1.TableParameters{StructuredQuery: &v1alpha1.StructuredQuery{
+				Conditions: []v1alpha1.QueryCondition{{QueryTerm: v1alpha1.QueryTerm{Field: "name"}}},
+			}},
+			want: want{err: errors.Wrap(errors.Errorf("wrong number of values for query operator =: 0"), errInvalidQuery)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := encodedQuery(tc.p)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nencodedQuery(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.query, got); diff != "" {
+				t.Errorf("\n%s\nencodedQuery(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
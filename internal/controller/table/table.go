@@ -0,0 +1,26 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+// Package table queries and manages records of ServiceNow tables with the
+// Table API.
+package table
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
+)
+
+const (
+	errTrackPCUsage = "cannot track ProviderConfig usage"
+	errSchedule     = "cannot evaluate the write window of the ProviderConfig"
+)
+
+// Setup adds controllers that reconcile Table API managed resources.
+func Setup(mgr ctrl.Manager, o controller.Options) error {
+	if err := setupTable(mgr, o); err != nil {
+		return err
+	}
+	return setupTableRecordSet(mgr, o)
+}
@@ -0,0 +1,505 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package table
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/connection"
+	"github.com/crossplane/crossplane-runtime/pkg/controller"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/ratelimiter"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	sdkTable "github.com/anka-software/cmdb-sdk/pkg/client/table"
+
+	"github.com/crossplane/provider-cmdb/apis/table/v1alpha1"
+	apisv1alpha1 "github.com/crossplane/provider-cmdb/apis/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/schedule"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+	"github.com/crossplane/provider-cmdb/internal/controller/features"
+	"github.com/crossplane/provider-cmdb/internal/controller/ratelimit"
+	"github.com/crossplane/provider-cmdb/internal/tracing"
+)
+
+const (
+	errNotTableRecordSet = "managed resource is not a TableRecordSet custom resource"
+
+	errListRecords  = "cannot list records with Table API"
+	errCreateRecord = "cannot create record with Table API"
+	errUpdateRecord = "cannot update record with Table API"
+	errDeleteRecord = "cannot delete record with Table API"
+	errMissingKey   = "record has no value for the key field"
+	errDuplicateKey = "records have the same key"
+	errDeferred     = "records are deleted once the write window of the ProviderConfig opens"
+	errPruneQuery   = "prune requires a query, or every record of the table that is not in the set would be deleted"
+	errOutOfScope   = "created records do not match the query, and were deleted again"
+)
+
+// Reasons of the events recorded on a TableRecordSet.
+const (
+	reasonSyncedRecords  event.Reason = "SyncedRecords"
+	reasonDeletedRecords event.Reason = "DeletedRecords"
+	reasonPlannedChange  event.Reason = "PlannedChange"
+)
+
+const (
+	fieldSysID = "sys_id"
+
+	msgPlannedSync   = "Would create %d, update %d and delete %d records, but changes to ServiceNow are disabled"
+	msgPlannedDelete = "Would delete %d records, but changes to ServiceNow are disabled"
+)
+
+// setupTableRecordSet adds a controller that reconciles TableRecordSet managed
+// resources.
+func setupTableRecordSet(mgr ctrl.Manager, o controller.Options) error {
+	name := managed.ControllerName(v1alpha1.TableRecordSetGroupKind)
+
+	cps := []managed.ConnectionPublisher{managed.NewAPISecretPublisher(mgr.GetClient(), mgr.GetScheme())}
+	if o.Features.Enabled(features.EnableAlphaExternalSecretStores) {
+		cps = append(cps, connection.NewDetailsManager(mgr.GetClient(), apisv1alpha1.StoreConfigGroupVersionKind))
+	}
+
+	log := o.Logger.WithValues("controller", name)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+
+	r := managed.NewReconciler(mgr,
+		resource.ManagedKind(v1alpha1.TableRecordSetGroupVersionKind),
+		managed.WithExternalConnecter(&recordSetConnector{
+			kube:              mgr.GetClient(),
+			usage:             resource.NewProviderConfigUsageTracker(mgr.GetClient(), &apisv1alpha1.ProviderConfigUsage{}),
+			newServiceFnTable: table.NewTableClient,
+			log:               log,
+			record:            recorder,
+			dryRun:            o.Features.Enabled(features.DryRun),
+		}),
+		// The external name is the table of the records, set once they are
+		// in sync.
+		managed.WithInitializers(),
+		managed.WithLogger(log),
+		managed.WithPollInterval(o.PollInterval),
+		managed.WithRecorder(recorder),
+		managed.WithConnectionPublishers(cps...))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		WithOptions(o.ForControllerRuntime()).
+		For(&v1alpha1.TableRecordSet{}).
+		Complete(ratelimiter.NewReconciler(name, tracing.NewReconciler(name, ratelimit.NewReconciler(mgr.GetClient(), resource.ManagedKind(v1alpha1.TableRecordSetGroupVersionKind), r, log)), o.GlobalRateLimiter))
+}
+
+// A recordSetConnector is expected to produce an ExternalClient when its
+// Connect method is called.
+type recordSetConnector struct {
+	kube              client.Client
+	usage             resource.Tracker
+	newServiceFnTable func(cfg clients.Config) sdkTable.ClientService
+	log               logging.Logger
+	record            event.Recorder
+	dryRun            bool
+}
+
+// Connect produces an ExternalClient for the ProviderConfig of the supplied
+// TableRecordSet.
+func (c *recordSetConnector) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	cr, ok := mg.(*v1alpha1.TableRecordSet)
+	if !ok {
+		return nil, errors.New(errNotTableRecordSet)
+	}
+
+	if err := c.usage.Track(ctx, mg); err != nil {
+		return nil, errors.Wrap(err, errTrackPCUsage)
+	}
+
+	cfg, err := clients.GetConfig(ctx, c.kube, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	log := c.log.WithValues("resource", cr.GetName(), "table", cr.Spec.ForProvider.TableName)
+	serviceTable := c.newServiceFnTable(*cfg)
+
+	return &recordSetExternal{serviceTable: serviceTable, log: log, record: c.record, readOnly: c.dryRun || cfg.ReadOnly, gate: schedule.NewGate(*cfg, serviceTable)}, nil
+}
+
+// A recordSetExternal keeps the records of a table in sync with a
+// TableRecordSet. Read only clients report the changes they would make in
+// Observe, and report the records as up to date so that they are not made.
+type recordSetExternal struct {
+	serviceTable sdkTable.ClientService
+	log          logging.Logger
+	record       event.Recorder
+
+	// readOnly clients plan the changes they would make to ServiceNow
+	// instead of making them.
+	readOnly bool
+
+	// gate defers changes outside the write windows of the ProviderConfig.
+	gate *schedule.Gate
+
+	// changes computed by Observe, and made by a subsequent Create, Update
+	// or Delete.
+	changes *changes
+}
+
+// An update of the fields of a record.
+type update struct {
+	v1alpha1.TableRecord
+	values map[string]string
+}
+
+// The changes that bring the records of a table in sync with a
+// TableRecordSet.
+type changes struct {
+	create []map[string]string
+	update []update
+	delete []v1alpha1.TableRecord
+
+	// records of the set that exist in the table, ordered by key.
+	records []v1alpha1.TableRecord
+}
+
+func (c *changes) empty() bool {
+	return len(c.create) == 0 && len(c.update) == 0 && len(c.delete) == 0
+}
+
+func (c *recordSetExternal) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	cr, ok := mg.(*v1alpha1.TableRecordSet)
+	if !ok {
+		return managed.ExternalObservation{}, errors.New(errNotTableRecordSet)
+	}
+
+	if p := cr.Spec.ForProvider; p.Prune && p.Query == "" && !meta.WasDeleted(cr) {
+		return managed.ExternalObservation{}, errors.New(errPruneQuery)
+	}
+
+	ch, err := c.observe(ctx, cr)
+	if err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	c.changes = ch
+	cr.Status.AtProvider.Records = ch.records
+
+	if meta.WasDeleted(cr) {
+		if c.readOnly && len(ch.records) > 0 {
+			// The records are left in place, so that the TableRecordSet can
+			// be deleted.
+			c.record.Event(cr, event.Normal(reasonPlannedChange, fmt.Sprintf(msgPlannedDelete, len(ch.records))))
+			return managed.ExternalObservation{}, nil
+		}
+		return managed.ExternalObservation{ResourceExists: len(ch.records) > 0}, nil
+	}
+
+	exists := meta.GetExternalName(cr) != ""
+	upToDate := exists && ch.empty()
+
+	if c.readOnly {
+		cr.Status.AtProvider.PlannedChange = nil
+		if !ch.empty() {
+			c.plan(cr, ch)
+		}
+		return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil
+	}
+	cr.Status.AtProvider.PlannedChange = nil
+
+	if !upToDate {
+		open, err := c.gate.Open(ctx, cr)
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errSchedule)
+		}
+		if !open {
+			c.log.Debug("Deferring changes to records until the write window opens", "create", len(ch.create), "update", len(ch.update), "delete", len(ch.delete))
+			return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, nil
+		}
+	}
+
+	if exists {
+		cr.SetConditions(xpv1.Available())
+	}
+
+	return managed.ExternalObservation{ResourceExists: exists, ResourceUpToDate: upToDate}, nil
+}
+
+func (c *recordSetExternal) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cr, ok := mg.(*v1alpha1.TableRecordSet)
+	if !ok {
+		return managed.ExternalCreation{}, errors.New(errNotTableRecordSet)
+	}
+
+	cr.Status.SetConditions(xpv1.Creating())
+
+	if err := c.sync(ctx, cr); err != nil {
+		return managed.ExternalCreation{}, err
+	}
+	meta.SetExternalName(cr, cr.Spec.ForProvider.TableName)
+	return managed.ExternalCreation{}, nil
+}
+
+func (c *recordSetExternal) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cr, ok := mg.(*v1alpha1.TableRecordSet)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotTableRecordSet)
+	}
+
+	return managed.ExternalUpdate{}, c.sync(ctx, cr)
+}
+
+// Delete deletes the records of the set. Records in scope that are not in
+// the set are left in place.
+func (c *recordSetExternal) Delete(ctx context.Context, mg resource.Managed) error {
+	cr, ok := mg.(*v1alpha1.TableRecordSet)
+	if !ok {
+		return errors.New(errNotTableRecordSet)
+	}
+
+	cr.Status.SetConditions(xpv1.Deleting())
+
+	records := cr.Status.AtProvider.Records
+	if c.changes != nil {
+		records = c.changes.records
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	open, err := c.gate.Open(ctx, cr)
+	if err != nil {
+		return errors.Wrap(err, errSchedule)
+	}
+	if !open {
+		return errors.New(errDeferred)
+	}
+
+	for _, r := range records {
+		if err := c.deleteRecord(ctx, cr.Spec.ForProvider.TableName, r); err != nil {
+			return err
+		}
+	}
+	c.log.Info("Deleted records", "records", len(records))
+	c.record.Event(cr, event.Normal(reasonDeletedRecords, fmt.Sprintf("Deleted %d records", len(records))))
+	cr.Status.AtProvider.Records = nil
+	return nil
+}
+
+// observe lists the records in the scope of the supplied TableRecordSet, and
+// returns the changes that bring them in sync with it.
+func (c *recordSetExternal) observe(ctx context.Context, cr *v1alpha1.TableRecordSet) (*changes, error) {
+	p := &cr.Spec.ForProvider
+
+	start := time.Now()
+	spanCtx, span := tracing.Start(ctx, "ServiceNow Table GetTableItems", attribute.String("servicenow.table", p.TableName))
+	current, err := table.ReadAll(c.serviceTable, table.GenerateQueryOptions(spanCtx, p.TableName, p.Query), table.ReadOptions{
+		Fields:               recordFields(p),
+		ExcludeReferenceLink: true,
+	})
+	tracing.End(span, err)
+	c.log.Debug("Queried records with Table API", "records", len(current), "duration", time.Since(start))
+	if e, ok := clients.AsUnavailable(err); ok {
+		cr.SetConditions(e.Condition())
+	}
+	if err != nil {
+		return nil, errors.Wrap(clients.Annotate(err), errListRecords)
+	}
+
+	return compare(p, current)
+}
+
+// recordFields returns the fields of the records of the supplied
+// TableRecordSet that are read: their sys_id, key and declared fields.
+func recordFields(p *v1alpha1.TableRecordSetParameters) []string {
+	fields := map[string]bool{fieldSysID: true, p.KeyField: true}
+	for _, r := range p.Records {
+		for k := range r {
+			fields[k] = true
+		}
+	}
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// compare returns the changes that bring the supplied records of a table in
+// sync with the supplied TableRecordSet. Only the fields the records of the
+// table have are compared. Records in scope that share the key of another
+// are not in the set.
+func compare(p *v1alpha1.TableRecordSetParameters, current []map[string]interface{}) (*changes, error) {
+	desired := make(map[string]map[string]string, len(p.Records))
+	for i, r := range p.Records {
+		key := r[p.KeyField]
+		if key == "" {
+			return nil, errors.Errorf("%s %s: record %d", errMissingKey, p.KeyField, i)
+		}
+		if _, ok := desired[key]; ok {
+			return nil, errors.Errorf("%s %s=%s", errDuplicateKey, p.KeyField, key)
+		}
+		desired[key] = r
+	}
+
+	sorted := append([]map[string]interface{}{}, current...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return table.FieldValue(sorted[i][fieldSysID]) < table.FieldValue(sorted[j][fieldSysID])
+	})
+
+	ch := &changes{}
+	found := map[string]bool{}
+	for _, r := range sorted {
+		rec := v1alpha1.TableRecord{Key: table.FieldValue(r[p.KeyField]), SysID: table.FieldValue(r[fieldSysID])}
+		values, ok := desired[rec.Key]
+		if !ok || found[rec.Key] {
+			if p.Prune {
+				ch.delete = append(ch.delete, rec)
+			}
+			continue
+		}
+		found[rec.Key] = true
+		ch.records = append(ch.records, rec)
+
+		changed := map[string]string{}
+		for k, v := range values {
+			if cur, ok := r[k]; ok && table.FieldValue(cur) != v {
+				changed[k] = v
+			}
+		}
+		if len(changed) > 0 {
+			ch.update = append(ch.update, update{TableRecord: rec, values: changed})
+		}
+	}
+	for _, r := range p.Records {
+		if !found[r[p.KeyField]] {
+			ch.create = append(ch.create, r)
+		}
+	}
+	sort.Slice(ch.records, func(i, j int) bool { return ch.records[i].Key < ch.records[j].Key })
+	return ch, nil
+}
+
+// plan reports the supplied changes in the status of the TableRecordSet.
+func (c *recordSetExternal) plan(cr *v1alpha1.TableRecordSet, ch *changes) {
+	pc := &v1alpha1.TableRecordSetChange{PlannedTime: metav1.Now()}
+	for _, r := range ch.create {
+		pc.Create = append(pc.Create, r[cr.Spec.ForProvider.KeyField])
+	}
+	for _, u := range ch.update {
+		pc.Update = append(pc.Update, u.Key)
+	}
+	for _, r := range ch.delete {
+		pc.Delete = append(pc.Delete, r.Key)
+	}
+	cr.Status.AtProvider.PlannedChange = pc
+
+	c.log.Info("Not changing records in dry run mode", "create", len(ch.create), "update", len(ch.update), "delete", len(ch.delete))
+	c.record.Event(cr, event.Normal(reasonPlannedChange, fmt.Sprintf(msgPlannedSync, len(ch.create), len(ch.update), len(ch.delete))))
+}
+
+// sync makes the changes computed by Observe.
+func (c *recordSetExternal) sync(ctx context.Context, cr *v1alpha1.TableRecordSet) error {
+	ch := c.changes
+	if ch == nil {
+		var err error
+		if ch, err = c.observe(ctx, cr); err != nil {
+			return err
+		}
+	}
+	name := cr.Spec.ForProvider.TableName
+
+	created := make([]v1alpha1.TableRecord, 0, len(ch.create))
+	for _, values := range ch.create {
+		spanCtx, span := tracing.Start(ctx, "ServiceNow Table InsertRecord", attribute.String("servicenow.table", name))
+		resp, err := c.serviceTable.GetTableItems(table.GenerateInsertRecordOptions(spanCtx, name), table.WithRecord(values))
+		tracing.End(span, err)
+		if err != nil {
+			return errors.Wrapf(clients.Annotate(err), "%s %s=%s", errCreateRecord, cr.Spec.ForProvider.KeyField, values[cr.Spec.ForProvider.KeyField])
+		}
+		rec := v1alpha1.TableRecord{Key: values[cr.Spec.ForProvider.KeyField]}
+		if len(resp.Payload.Result) > 0 {
+			rec.SysID = table.FieldValue(resp.Payload.Result[0][fieldSysID])
+		}
+		created = append(created, rec)
+	}
+	for _, u := range ch.update {
+		spanCtx, span := tracing.Start(ctx, "ServiceNow Table UpdateRecord", attribute.String("servicenow.table", name))
+		_, err := c.serviceTable.GetTableItems(table.GenerateUpdateRecordOptions(spanCtx, name), table.WithUpdatedRecord(u.SysID, u.values))
+		tracing.End(span, err)
+		if err != nil {
+			return errors.Wrapf(clients.Annotate(err), "%s %s", errUpdateRecord, u.SysID)
+		}
+	}
+	for _, r := range ch.delete {
+		if err := c.deleteRecord(ctx, name, r); err != nil {
+			return err
+		}
+	}
+	if err := c.inScope(ctx, cr, created); err != nil {
+		return err
+	}
+
+	if !ch.empty() {
+		c.log.Info("Synced records", "created", len(ch.create), "updated", len(ch.update), "deleted", len(ch.delete))
+		c.record.Event(cr, event.Normal(reasonSyncedRecords, fmt.Sprintf("Created %d, updated %d and deleted %d records", len(ch.create), len(ch.update), len(ch.delete))))
+	}
+	cr.SetConditions(xpv1.Available())
+	return nil
+}
+
+// inScope returns an error if any of the supplied created records does not
+// match the query of the TableRecordSet, in which case it would be missing
+// from the set and created again on every poll. Such records are deleted
+// again, so that they do not pile up.
+func (c *recordSetExternal) inScope(ctx context.Context, cr *v1alpha1.TableRecordSet, created []v1alpha1.TableRecord) error {
+	if len(created) == 0 || cr.Spec.ForProvider.Query == "" {
+		return nil
+	}
+	ch, err := c.observe(ctx, cr)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]bool, len(ch.records))
+	for _, r := range ch.records {
+		found[r.SysID] = true
+	}
+
+	var keys []string
+	for _, r := range created {
+		if r.SysID == "" || found[r.SysID] {
+			continue
+		}
+		if err := c.deleteRecord(ctx, cr.Spec.ForProvider.TableName, r); err != nil {
+			return err
+		}
+		keys = append(keys, r.Key)
+	}
+	if len(keys) > 0 {
+		return errors.Errorf("%s: %s=%s", errOutOfScope, cr.Spec.ForProvider.KeyField, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// deleteRecord deletes the supplied record, unless it no longer exists.
+func (c *recordSetExternal) deleteRecord(ctx context.Context, name string, r v1alpha1.TableRecord) error {
+	spanCtx, span := tracing.Start(ctx, "ServiceNow Table DeleteRecord", attribute.String("servicenow.table", name))
+	_, err := c.serviceTable.DeleteRecord(table.GenerateDeleteRecordOptions(spanCtx, name, r.SysID))
+	tracing.End(span, err)
+	if err != nil && !clients.IsNotFound(err) {
+		return errors.Wrapf(clients.Annotate(err), "%s %s", errDeleteRecord, r.SysID)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+/*
+ Copyright 2022 The ANKA SOFTWARE Authors.
+*/
+
+package table
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-cmdb/apis/table/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+	"github.com/crossplane/provider-cmdb/internal/fake/servicenow"
+)
+
+const tableChoice = "sys_choice"
+
+func recordSet(prune bool, records ...map[string]string) *v1alpha1.TableRecordSet {
+	return &v1alpha1.TableRecordSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "categories"},
+		Spec: v1alpha1.TableRecordSetSpec{ForProvider: v1alpha1.TableRecordSetParameters{
+			TableName: tableChoice,
+			Query:     "name=incident^element=category",
+			KeyField:  "value",
+			Records:   records,
+			Prune:     prune,
+		}},
+	}
+}
+
+func choice(value, label string) map[string]string {
+	return map[string]string{"name": "incident", "element": "category", "value": value, "label": label}
+}
+
+func TestCompare(t *testing.T) {
+	type want struct {
+		create  int
+		update  []update
+		delete  []v1alpha1.TableRecord
+		records []v1alpha1.TableRecord
+		err     error
+	}
+
+	current := []map[string]interface{}{
+		{"sys_id": "2", "value": "network", "label": "Net"},
+		{"sys_id": "1", "value": "hardware", "label": "Hardware", "dependent": map[string]interface{}{"link": "https://example/1", "value": "x"}},
+		{"sys_id": "3", "value": "network", "label": "Network"},
+		{"sys_id": "4", "value": "database", "label": "Database"},
+	}
+
+	cases := map[string]struct {
+		reason string
+		p      *v1alpha1.TableRecordSetParameters
+		want   want
+	}{
+		"Sync": {
+			reason: "Missing records should be created and changed records updated, leaving undeclared records alone.",
+			p: &recordSet(false,
+				map[string]string{"value": "hardware", "label": "Hardware", "dependent": "x", "unknown": "y"},
+				map[string]string{"value": "network", "label": "Network"},
+				map[string]string{"value": "software", "label": "Software"},
+			).Spec.ForProvider,
+			want: want{
+				create:  1,
+				update:  []update{{TableRecord: v1alpha1.TableRecord{Key: "network", SysID: "2"}, values: map[string]string{"label": "Network"}}},
+				records: []v1alpha1.TableRecord{{Key: "hardware", SysID: "1"}, {Key: "network", SysID: "2"}},
+			},
+		},
+		"Prune": {
+			reason: "Records in scope that are not declared, or share the key of another, should be deleted if the set is pruned.",
+			p:      &recordSet(true, map[string]string{"value": "hardware"}, map[string]string{"value": "network"}).Spec.ForProvider,
+			want: want{
+				delete:  []v1alpha1.TableRecord{{Key: "network", SysID: "3"}, {Key: "database", SysID: "4"}},
+				records: []v1alpha1.TableRecord{{Key: "hardware", SysID: "1"}, {Key: "network", SysID: "2"}},
+			},
+		},
+		"MissingKey": {
+			reason: "Records without a key should be rejected.",
+			p:      &recordSet(false, map[string]string{"label": "Hardware"}).Spec.ForProvider,
+			want:   want{err: errors.Errorf("%s %s: record %d", errMissingKey, "value", 0)},
+		},
+		"DuplicateKey": {
+			reason: "Records with the same key should be rejected.",
+			p:      &recordSet(false, map[string]string{"value": "hardware"}, map[string]string{"value": "hardware"}).Spec.ForProvider,
+			want:   want{err: errors.Errorf("%s %s=%s", errDuplicateKey, "value", "hardware")},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := compare(tc.p, current)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Fatalf("\n%s\ncompare(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			if len(got.create) != tc.want.create {
+				t.Errorf("\n%s\ncompare(...): want %d records created, got %d", tc.reason, tc.want.create, len(got.create))
+			}
+			if diff := cmp.Diff(tc.want.update, got.update, cmp.AllowUnexported(update{})); diff != "" {
+				t.Errorf("\n%s\ncompare(...): -want updates, +got updates:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.delete, got.delete); diff != "" {
+				t.Errorf("\n%s\ncompare(...): -want deletes, +got deletes:\n%s\n", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.records, got.records); diff != "" {
+				t.Errorf("\n%s\ncompare(...): -want records, +got records:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestTableRecordSetLifecycle(t *testing.T) {
+	sn := servicenow.NewServer(servicenow.WithTable(tableChoice))
+	defer sn.Close()
+
+	ctx := context.Background()
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+	newExternal := func(readOnly bool) *recordSetExternal {
+		return &recordSetExternal{serviceTable: table.NewTableClient(cfg), log: logging.NewNopLogger(), record: event.NewNopRecorder(), readOnly: readOnly}
+	}
+
+	hardware := sn.Insert(tableChoice, servicenow.Record(choice("hardware", "Hardware")))
+	network := sn.Insert(tableChoice, servicenow.Record(choice("network", "Net")))
+	stale := sn.Insert(tableChoice, servicenow.Record(choice("inquiry", "Inquiry")))
+	other := sn.Insert(tableChoice, servicenow.Record{"name": "problem", "element": "category", "value": "inquiry"})
+
+	cr := recordSet(true, choice("hardware", "Hardware"), choice("network", "Network"), choice("software", "Software"))
+
+	o, err := newExternal(true).Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if diff := cmp.Diff(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, o); diff != "" {
+		t.Errorf("Observe(...): read only: -want, +got:\n%s\n", diff)
+	}
+	want := &v1alpha1.TableRecordSetChange{Create: []string{"software"}, Update: []string{"network"}, Delete: []string{"inquiry"}}
+	if diff := cmp.Diff(want, cr.Status.AtProvider.PlannedChange, cmp.FilterPath(func(p cmp.Path) bool { return p.Last().String() == ".PlannedTime" }, cmp.Ignore())); diff != "" {
+		t.Errorf("Observe(...): read only: -want planned change, +got:\n%s\n", diff)
+	}
+
+	e := newExternal(false)
+	o, err = e.Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if diff := cmp.Diff(managed.ExternalObservation{}, o); diff != "" {
+		t.Errorf("Observe(...): unsynced records: -want, +got:\n%s\n", diff)
+	}
+	if cr.Status.AtProvider.PlannedChange != nil {
+		t.Errorf("Observe(...): want no planned change, got %v", cr.Status.AtProvider.PlannedChange)
+	}
+	if _, err := e.Create(ctx, cr); err != nil {
+		t.Fatalf("Create(...): %v", err)
+	}
+	if got := meta.GetExternalName(cr); got != tableChoice {
+		t.Errorf("Create(...): want external name %q, got %q", tableChoice, got)
+	}
+	if r, _ := sn.Get(network); r["label"] != "Network" {
+		t.Errorf("Create(...): want the changed record to be updated, got %v", r)
+	}
+	if _, ok := sn.Get(stale); ok {
+		t.Errorf("Create(...): want the undeclared record to be pruned")
+	}
+	if _, ok := sn.Get(other); !ok {
+		t.Errorf("Create(...): want the record out of scope to be left alone")
+	}
+
+	o, err = newExternal(false).Observe(ctx, cr)
+	if err != nil {
+		t.Fatalf("Observe(...): %v", err)
+	}
+	if diff := cmp.Diff(managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true}, o); diff != "" {
+		t.Errorf("Observe(...): synced records: -want, +got:\n%s\n", diff)
+	}
+	if got := len(cr.Status.AtProvider.Records); got != 3 {
+		t.Errorf("Observe(...): want 3 records, got %d", got)
+	}
+
+	now := metav1.Now()
+	cr.SetDeletionTimestamp(&now)
+	e = newExternal(false)
+	if o, err = e.Observe(ctx, cr); err != nil || !o.ResourceExists {
+		t.Fatalf("Observe(...): want a deleted set with records to exist, got %v, %v", o, err)
+	}
+	if err := e.Delete(ctx, cr); err != nil {
+		t.Fatalf("Delete(...): %v", err)
+	}
+	for _, id := range []string{hardware, network} {
+		if _, ok := sn.Get(id); ok {
+			t.Errorf("Delete(...): want record %s to be deleted", id)
+		}
+	}
+	if got := len(sn.Records(tableChoice)); got != 1 {
+		t.Errorf("Delete(...): want only the record out of scope to remain, got %d records", got)
+	}
+	if o, err = newExternal(false).Observe(ctx, cr); err != nil || o.ResourceExists {
+		t.Errorf("Observe(...): want a deleted set without records not to exist, got %v, %v", o, err)
+	}
+}
+
+func TestTableRecordSetScope(t *testing.T) {
+	sn := servicenow.NewServer(servicenow.WithTable(tableChoice))
+	defer sn.Close()
+
+	ctx := context.Background()
+	cfg := clients.Config{BaseURL: sn.URL, Username: servicenow.DefaultUsername, Password: servicenow.DefaultPassword, ProviderConfigName: t.Name()}
+	newExternal := func() *recordSetExternal {
+		return &recordSetExternal{serviceTable: table.NewTableClient(cfg), log: logging.NewNopLogger(), record: event.NewNopRecorder()}
+	}
+	problem := func(value, label string) map[string]string {
+		return map[string]string{"name": "problem", "element": "category", "value": value, "label": label}
+	}
+
+	cases := map[string]struct {
+		reason string
+		cr     *v1alpha1.TableRecordSet
+		want   error
+	}{
+		"PruneWithoutQuery": {
+			reason: "A set that prunes every other record of its table should be rejected.",
+			cr: func() *v1alpha1.TableRecordSet {
+				cr := recordSet(true, choice("hardware", "Hardware"))
+				cr.Spec.ForProvider.Query = ""
+				return cr
+			}(),
+			want: errors.New(errPruneQuery),
+		},
+		"OutOfScope": {
+			reason: "Records that do not match the query once they are created should be deleted again and reported.",
+			cr:     recordSet(false, choice("hardware", "Hardware"), problem("inquiry", "Inquiry")),
+			want:   errors.Errorf("%s: %s=%s", errOutOfScope, "value", "inquiry"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := newExternal()
+			_, err := e.Observe(ctx, tc.cr)
+			if err == nil {
+				_, err = e.Create(ctx, tc.cr)
+			}
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nObserve(...), Create(...): -want error, +got error:\n%s\n", tc.reason, diff)
+			}
+			for _, r := range sn.Records(tableChoice) {
+				if r["name"] == "problem" {
+					t.Errorf("\n%s\nCreate(...): want no record out of scope, got %v", tc.reason, r)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicenow
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Fault replaces the responses to matching requests.
+type Fault struct {
+	// Method of the requests the fault applies to, or any method if empty.
+	Method string
+
+	// Path prefix of the requests the fault applies to, for example
+	// /api/now/table/cmdb_ci, or any path if empty.
+	Path string
+
+	// Times the fault is injected before it is removed, or until it is
+	// cleared if zero.
+	Times int
+
+	// Delay before responding. A fault that only delays requests is served
+	// by the instance once the delay has passed.
+	Delay time.Duration
+
+	// StatusCode, Header and Body of the response. The request is served
+	// by the instance if StatusCode is zero.
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// matches returns true if the fault applies to the supplied request.
+func (f *Fault) matches(r *http.Request) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+		return false
+	}
+	return strings.HasPrefix(r.URL.Path, f.Path)
+}
+
+// InjectFault makes the instance respond to matching requests with the
+// supplied fault. Faults are matched in the order they were injected.
+func (i *Instance) InjectFault(f Fault) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults = append(i.faults, &f)
+}
+
+// ClearFaults removes every injected fault.
+func (i *Instance) ClearFaults() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults = nil
+}
+
+// fault returns a copy of the first fault that applies to the supplied
+// request, if any, consuming one of its times.
+func (i *Instance) fault(r *http.Request) *Fault {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for n, f := range i.faults {
+		if !f.matches(r) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				i.faults = append(i.faults[:n], i.faults[n+1:]...)
+			}
+		}
+		out := *f
+		return &out
+	}
+	return nil
+}
+
+// StatusFault responds with the supplied status code and a ServiceNow error.
+func StatusFault(code int) Fault {
+	return Fault{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       `{"error":{"message":"` + http.StatusText(code) + `","detail":"Injected fault"},"status":"failure"}`,
+	}
+}
+
+// RateLimitedFault responds with 429 Too Many Requests, asking the client
+// to retry after the supplied duration.
+func RateLimitedFault(retryAfter time.Duration) Fault {
+	f := StatusFault(http.StatusTooManyRequests)
+	f.Header.Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	return f
+}
+
+// HibernatingFault responds with the page of a hibernating developer
+// instance.
+func HibernatingFault() Fault {
+	return Fault{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=UTF-8"}},
+		Body:       `<html><head><title>Instance Hibernating page</title></head><body><p>Your instance is hibernating. Sign in to the developer portal to wake it up.</p></body></html>`,
+	}
+}
+
+// MaintenanceFault responds with the page of an instance that is down for
+// maintenance.
+func MaintenanceFault() Fault {
+	return Fault{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Content-Type": {"text/html; charset=UTF-8"}},
+		Body:       `<html><head><title>Maintenance</title></head><body><p>This instance is currently undergoing maintenance.</p></body></html>`,
+	}
+}
+
+// LoginRedirectFault redirects requests to the single sign-on login page.
+func LoginRedirectFault() Fault {
+	return Fault{
+		StatusCode: http.StatusFound,
+		Header:     http.Header{"Location": {"/login_with_sso.do"}},
+	}
+}
+
+// DelayFault delays matching requests by the supplied duration.
+func DelayFault(d time.Duration) Fault {
+	return Fault{Delay: d}
+}
@@ -0,0 +1,499 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicenow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	pathAPI   = "/api/now"
+	pathToken = "/oauth_token.do"
+
+	headerTransactionID = "X-Transaction-ID"
+	headerTotalCount    = "X-Total-Count"
+	headerLink          = "Link"
+
+	// defaultLimit is the number of records the Table API returns if the
+	// request does not ask for a limit.
+	defaultLimit = 10000
+)
+
+// Operations of the Identification and Reconciliation API.
+const (
+	OperationInsert   = "INSERT"
+	OperationUpdate   = "UPDATE"
+	OperationNoChange = "NO_CHANGE"
+)
+
+// ServeHTTP serves the REST APIs of the fake instance.
+func (i *Instance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Cannot read request body", err.Error())
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	i.mu.Lock()
+	i.requests = append(i.requests, Request{Method: r.Method, Path: r.URL.Path, RawQuery: r.URL.RawQuery, Body: string(body)})
+	w.Header().Set(headerTransactionID, fmt.Sprintf("%032x", len(i.requests)))
+	i.mu.Unlock()
+
+	if f := i.fault(r); f != nil {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(f.Delay):
+		}
+		if f.StatusCode != 0 {
+			for k, v := range f.Header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(f.StatusCode)
+			_, _ = io.WriteString(w, f.Body)
+			return
+		}
+	}
+
+	switch {
+	case r.URL.Path == pathToken:
+		i.serveToken(w, r)
+	case strings.HasPrefix(r.URL.Path, pathAPI+"/"):
+		if !i.authenticated(r) {
+			writeError(w, http.StatusUnauthorized, "User Not Authenticated", "Required to provide Auth information")
+			return
+		}
+		i.serveAPI(w, r, strings.TrimPrefix(r.URL.Path, pathAPI), body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (i *Instance) serveAPI(w http.ResponseWriter, r *http.Request, path string, body []byte) {
+	switch {
+	case strings.HasPrefix(path, "/table/"):
+		i.serveTable(w, r, strings.TrimPrefix(path, "/table/"), body)
+	case path == "/identifyreconcile" && r.Method == http.MethodPost:
+		i.serveIdentifyReconcile(w, r, body, true)
+	case path == "/identifyreconcile/query" && r.Method == http.MethodPost:
+		i.serveIdentifyReconcile(w, r, body, false)
+	case strings.HasPrefix(path, "/cmdb/meta/") && r.Method == http.MethodGet:
+		i.serveMeta(w, strings.TrimPrefix(path, "/cmdb/meta/"))
+	default:
+		writeError(w, http.StatusBadRequest, "Requested URI does not represent any resource", path)
+	}
+}
+
+// authenticated returns true if the request carries the credentials of the
+// instance, or a valid access token.
+func (i *Instance) authenticated(r *http.Request) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if username, password, ok := r.BasicAuth(); ok {
+		return username == i.username && password == i.password
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	expires, ok := i.tokens[token]
+	return ok && i.now().Before(expires)
+}
+
+func (i *Instance) serveTable(w http.ResponseWriter, r *http.Request, path string, body []byte) { //nolint:gocyclo // A flat switch over the methods.
+	table, sysID := path, ""
+	if n := strings.Index(path, "/"); n >= 0 {
+		table, sysID = path[:n], path[n+1:]
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.hasTable(table) {
+		writeError(w, http.StatusBadRequest, "Invalid table "+table, nil)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && sysID == "":
+		i.listRecords(w, r, table)
+	case r.Method == http.MethodPost && sysID == "":
+		values, err := decodeValues(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Exception while reading request", err.Error())
+			return
+		}
+		id := i.insert(table, values)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"result": i.records[table][id]})
+	case sysID == "":
+		writeError(w, http.StatusMethodNotAllowed, "Method not supported", r.Method)
+	default:
+		rec, ok := i.get(sysID)
+		if !ok || (rec[FieldSysClassName] != table && !i.isClass(rec[FieldSysClassName], table)) {
+			writeError(w, http.StatusNotFound, "No Record found", "Record doesn't exist or ACL restricts the record retrieval")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, map[string]interface{}{"result": project(rec, r.URL.Query().Get("sysparm_fields"))})
+		case http.MethodPut, http.MethodPatch:
+			values, err := decodeValues(body)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "Exception while reading request", err.Error())
+				return
+			}
+			i.update(sysID, values)
+			writeJSON(w, http.StatusOK, map[string]interface{}{"result": rec})
+		case http.MethodDelete:
+			i.delete(sysID)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method not supported", r.Method)
+		}
+	}
+}
+
+func (i *Instance) listRecords(w http.ResponseWriter, r *http.Request, table string) {
+	params := r.URL.Query()
+	q, err := parseQuery(params.Get("sysparm_query"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid query", err.Error())
+		return
+	}
+
+	var matched []Record
+	for _, rec := range i.list(table) {
+		if q.matches(rec) {
+			matched = append(matched, rec)
+		}
+	}
+	q.sort(matched)
+
+	offset, _ := strconv.Atoi(params.Get("sysparm_offset"))
+	limit, err := strconv.Atoi(params.Get("sysparm_limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+
+	result := []Record{}
+	for n := offset; n < len(matched) && n < offset+limit; n++ {
+		result = append(result, project(matched[n], params.Get("sysparm_fields")))
+	}
+
+	w.Header().Set(headerTotalCount, strconv.Itoa(len(matched)))
+	if links := pageLinks(r, offset, limit, len(matched)); links != "" {
+		w.Header().Set(headerLink, links)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"result": result})
+}
+
+// pageLinks returns the Link header of a page of the supplied size at the
+// supplied offset, if there is more than one page.
+func pageLinks(r *http.Request, offset, limit, total int) string {
+	if offset == 0 && total <= limit {
+		return ""
+	}
+	link := func(o int, rel string) string {
+		q := r.URL.Query()
+		q.Set("sysparm_offset", strconv.Itoa(o))
+		q.Set("sysparm_limit", strconv.Itoa(limit))
+		u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawQuery: q.Encode()}
+		return fmt.Sprintf("<%s>;rel=%q", u.String(), rel)
+	}
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+	links := []string{link(0, "first")}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, link(prev, "prev"))
+	}
+	if offset+limit < total {
+		links = append(links, link(offset+limit, "next"))
+	}
+	return strings.Join(append(links, link(last, "last")), ",")
+}
+
+// project returns the supplied comma separated fields of the record, or the
+// whole record if no fields are supplied.
+func project(r Record, fields string) Record {
+	if fields == "" {
+		return r
+	}
+	out := Record{}
+	for _, f := range strings.Split(fields, ",") {
+		if v, ok := r[f]; ok {
+			out[f] = v
+		}
+	}
+	return out
+}
+
+// An ireRequest is the payload of the Identification and Reconciliation API.
+type ireRequest struct {
+	Items []struct {
+		ClassName string            `json:"className"`
+		Values    map[string]string `json:"values"`
+	} `json:"items"`
+	Relations []struct {
+		Parent int    `json:"parent"`
+		Child  int    `json:"child"`
+		Type   string `json:"type"`
+	} `json:"relations"`
+}
+
+type ireItem struct {
+	ClassName string `json:"className"`
+	Operation string `json:"operation"`
+	SysID     string `json:"sysId"`
+}
+
+// serveIdentifyReconcile identifies CIs by serial number if one is supplied,
+// and otherwise by name. It inserts or updates them unless it only
+// identifies them.
+func (i *Instance) serveIdentifyReconcile(w http.ResponseWriter, r *http.Request, body []byte, commit bool) {
+	req := &ireRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid payload", err.Error())
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, item := range req.Items {
+		if _, ok := i.classes[item.ClassName]; !ok {
+			writeError(w, http.StatusBadRequest, "Invalid class name "+item.ClassName, "The class name is not a valid CMDB class")
+			return
+		}
+	}
+
+	source := r.URL.Query().Get("sysparm_data_source")
+	items := make([]ireItem, len(req.Items))
+	for n, item := range req.Items {
+		values := Record{}
+		for k, v := range item.Values {
+			values[k] = v
+		}
+		if source != "" {
+			values["discovery_source"] = source
+		}
+
+		out := ireItem{ClassName: item.ClassName, Operation: OperationInsert}
+		if existing := i.identify(item.ClassName, values); existing != nil {
+			out.SysID = existing[FieldSysID]
+			out.Operation = OperationNoChange
+			for k, v := range values {
+				if existing[k] != v {
+					out.Operation = OperationUpdate
+					break
+				}
+			}
+			if commit {
+				i.update(out.SysID, values)
+			}
+		} else if commit {
+			out.SysID = i.insert(item.ClassName, values)
+		}
+		items[n] = out
+	}
+
+	relations := []ireItem{}
+	for _, rel := range req.Relations {
+		if rel.Parent < 0 || rel.Parent >= len(items) || rel.Child < 0 || rel.Child >= len(items) {
+			writeError(w, http.StatusBadRequest, "Invalid relation", "Relations must refer to items of the payload")
+			return
+		}
+		values := Record{"parent": items[rel.Parent].SysID, "child": items[rel.Child].SysID, "type": rel.Type}
+		out := ireItem{ClassName: TableRelationships, Operation: OperationInsert}
+		for _, existing := range i.list(TableRelationships) {
+			if existing["parent"] == values["parent"] && existing["child"] == values["child"] && existing["type"] == values["type"] {
+				out.Operation, out.SysID = OperationNoChange, existing[FieldSysID]
+			}
+		}
+		if commit && out.SysID == "" {
+			out.SysID = i.insert(TableRelationships, values)
+		}
+		relations = append(relations, out)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"result": map[string]interface{}{
+		"items":                    items,
+		"relations":                relations,
+		"additionalCommittedItems": []ireItem{},
+	}})
+}
+
+// identify returns the CI of the supplied class hierarchy the supplied
+// values identify, if any.
+func (i *Instance) identify(class string, values Record) Record {
+	key := FieldName
+	if values["serial_number"] != "" {
+		key = "serial_number"
+	}
+	if values[key] == "" {
+		return nil
+	}
+	root := class
+	for c := i.classes[class]; c != nil && c.Parent != "" && c.Parent != "cmdb_ci"; c = i.classes[c.Parent] {
+		root = c.Parent
+	}
+	for _, r := range i.list(root) {
+		if r[key] == values[key] {
+			return r
+		}
+	}
+	return nil
+}
+
+type metaAttribute struct {
+	Element      string `json:"element"`
+	Label        string `json:"label"`
+	Type         string `json:"type"`
+	IsMandatory  string `json:"is_mandatory"`
+	IsDisplay    string `json:"is_display"`
+	DefaultValue string `json:"default_value"`
+}
+
+func (i *Instance) serveMeta(w http.ResponseWriter, class string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	c, ok := i.classes[class]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Invalid class name "+class, nil)
+		return
+	}
+
+	attrs := []metaAttribute{}
+	for _, a := range i.attributes(class) {
+		attrs = append(attrs, metaAttribute{
+			Element:     a,
+			Label:       label(a),
+			Type:        "string",
+			IsMandatory: strconv.FormatBool(a == FieldName),
+			IsDisplay:   strconv.FormatBool(a == FieldName),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"result": map[string]interface{}{
+		"name":       c.Name,
+		"label":      c.Name,
+		"parent":     c.Parent,
+		"attributes": attrs,
+	}})
+}
+
+// serveToken issues OAuth access tokens for the password, client
+// credentials and refresh token grants.
+func (i *Instance) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not supported", r.Method)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w)
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if r.PostForm.Get("client_id") != i.clientID || r.PostForm.Get("client_secret") != i.clientSecret {
+		writeOAuthError(w)
+		return
+	}
+	switch r.PostForm.Get("grant_type") {
+	case "password":
+		if r.PostForm.Get("username") != i.username || r.PostForm.Get("password") != i.password {
+			writeOAuthError(w)
+			return
+		}
+	case "client_credentials":
+	case "refresh_token":
+		if _, ok := i.tokens[r.PostForm.Get("refresh_token")]; !ok {
+			writeOAuthError(w)
+			return
+		}
+	default:
+		writeOAuthError(w)
+		return
+	}
+
+	access, refresh := i.newSysID(), i.newSysID()
+	i.tokens[access] = i.now().Add(i.tokenTTL)
+	// Refresh tokens are not valid access tokens, but are recorded the
+	// same way.
+	i.tokens[refresh] = time.Time{}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  access,
+		"refresh_token": refresh,
+		"scope":         "useraccount",
+		"token_type":    "Bearer",
+		"expires_in":    int(i.tokenTTL.Seconds()),
+	})
+}
+
+// label returns a label for the supplied element, for example Serial number
+// for serial_number.
+func label(element string) string {
+	l := strings.ReplaceAll(element, "_", " ")
+	return strings.ToUpper(l[:1]) + l[1:]
+}
+
+func decodeValues(body []byte) (Record, error) {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	values := Record{}
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			values[k] = s
+			continue
+		}
+		values[k] = fmt.Sprint(v)
+	}
+	return values, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string, detail interface{}) {
+	writeJSON(w, code, map[string]interface{}{
+		"error":  map[string]interface{}{"message": message, "detail": detail},
+		"status": "failure",
+	})
+}
+
+func writeOAuthError(w http.ResponseWriter) {
+	writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "server_error", "error_description": "access_denied"})
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicenow
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Operators of encoded queries, longest first so that they are matched
+// greedily.
+var operators = []string{
+	"ISNOTEMPTY", "ISEMPTY", "STARTSWITH", "ENDSWITH", "NOT IN", "NOTLIKE", "LIKE", "IN",
+	"!=", ">=", "<=", "=", ">", "<",
+}
+
+// A condition of an encoded query.
+type condition struct {
+	field    string
+	operator string
+	value    string
+}
+
+// A query is an encoded query: a disjunction of conjunctions of
+// disjunctions of conditions.
+type query struct {
+	alternatives [][][]condition
+	orderBy      []string // Fields, prefixed with - if descending.
+}
+
+// parseQuery parses the subset of ServiceNow encoded queries the fake
+// supports: conditions joined by ^, ^OR and ^NQ, and ORDERBY and
+// ORDERBYDESC terms. A doubled caret is a caret of a value.
+func parseQuery(s string) (*query, error) {
+	q := &query{}
+	if s == "" {
+		return q, nil
+	}
+	for _, alt := range splitTerms(s, "NQ") {
+		var and [][]condition
+		for _, term := range alt {
+			switch {
+			case term == "":
+				continue
+			case strings.HasPrefix(term, "ORDERBYDESC"):
+				q.orderBy = append(q.orderBy, "-"+strings.TrimPrefix(term, "ORDERBYDESC"))
+				continue
+			case strings.HasPrefix(term, "ORDERBY"):
+				q.orderBy = append(q.orderBy, strings.TrimPrefix(term, "ORDERBY"))
+				continue
+			}
+
+			or := strings.HasPrefix(term, "OR") && len(and) > 0
+			if or {
+				term = strings.TrimPrefix(term, "OR")
+			}
+			c, err := parseCondition(term)
+			if err != nil {
+				return nil, err
+			}
+			if or {
+				and[len(and)-1] = append(and[len(and)-1], c)
+				continue
+			}
+			and = append(and, []condition{c})
+		}
+		q.alternatives = append(q.alternatives, and)
+	}
+	return q, nil
+}
+
+// splitTerms splits an encoded query into the terms of the queries separated
+// by ^ followed by the supplied keyword, unescaping doubled carets.
+func splitTerms(s, newQuery string) [][]string {
+	var queries [][]string
+	var terms []string
+	var term strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '^' {
+			term.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '^' {
+			term.WriteByte('^')
+			i++
+			continue
+		}
+		terms = append(terms, term.String())
+		term.Reset()
+		if strings.HasPrefix(s[i+1:], newQuery) {
+			queries = append(queries, terms)
+			terms = nil
+			i += len(newQuery)
+		}
+	}
+	return append(queries, append(terms, term.String()))
+}
+
+func parseCondition(term string) (condition, error) {
+	end := strings.IndexFunc(term, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '.')
+	})
+	if end <= 0 {
+		return condition{}, errors.Errorf("invalid query condition %q", term)
+	}
+	rest := term[end:]
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			return condition{field: term[:end], operator: op, value: strings.TrimPrefix(rest, op)}, nil
+		}
+	}
+	return condition{}, errors.Errorf("unsupported operator in query condition %q", term)
+}
+
+// matches returns true if the supplied record satisfies the query.
+func (q *query) matches(r Record) bool {
+	if len(q.alternatives) == 0 {
+		return true
+	}
+	for _, and := range q.alternatives {
+		if matchesAll(and, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(and [][]condition, r Record) bool {
+	for _, or := range and {
+		matched := false
+		for _, c := range or {
+			if c.matches(r) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (c condition) matches(r Record) bool { //nolint:gocyclo // A flat switch over the operators.
+	v, ok := r[c.field]
+	switch c.operator {
+	case "ISEMPTY":
+		return v == ""
+	case "ISNOTEMPTY":
+		return v != ""
+	case "=":
+		return ok && v == c.value
+	case "!=":
+		return v != c.value
+	case "IN":
+		return ok && contains(strings.Split(c.value, ","), v)
+	case "NOT IN":
+		return !contains(strings.Split(c.value, ","), v)
+	case "STARTSWITH":
+		return strings.HasPrefix(strings.ToLower(v), strings.ToLower(c.value))
+	case "ENDSWITH":
+		return strings.HasSuffix(strings.ToLower(v), strings.ToLower(c.value))
+	case "LIKE":
+		return strings.Contains(strings.ToLower(v), strings.ToLower(c.value))
+	case "NOTLIKE":
+		return !strings.Contains(strings.ToLower(v), strings.ToLower(c.value))
+	case ">":
+		return ok && compare(v, c.value) > 0
+	case ">=":
+		return ok && compare(v, c.value) >= 0
+	case "<":
+		return ok && compare(v, c.value) < 0
+	case "<=":
+		return ok && compare(v, c.value) <= 0
+	}
+	return false
+}
+
+// sort orders the supplied records as the query asks.
+func (q *query) sort(rs []Record) {
+	if len(q.orderBy) == 0 {
+		return
+	}
+	sort.SliceStable(rs, func(a, b int) bool {
+		for _, f := range q.orderBy {
+			desc := strings.HasPrefix(f, "-")
+			f = strings.TrimPrefix(f, "-")
+			c := compare(rs[a][f], rs[b][f])
+			if c == 0 {
+				continue
+			}
+			return (c < 0) != desc
+		}
+		return false
+	})
+}
+
+// compare compares values numerically if both are numbers, and otherwise as
+// strings.
+func compare(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,415 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package servicenow is an in-memory fake of the ServiceNow REST APIs used
+// by the provider: the Table API, the Identification and Reconciliation API,
+// the CMDB Meta API and the OAuth token endpoint. It is meant to be used by
+// tests, and to run the provider locally without a ServiceNow instance.
+package servicenow
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Tables of the fake instance that are not CI classes.
+const (
+	TableSysProperties = "sys_properties"
+	TableRelationships = "cmdb_rel_ci"
+	TableChangeRequest = "change_request"
+	TableSchedule      = "cmn_schedule"
+	TableScheduleSpan  = "cmn_schedule_span"
+)
+
+// Fields every record has.
+const (
+	FieldSysID        = "sys_id"
+	FieldSysClassName = "sys_class_name"
+	FieldName         = "name"
+	FieldCreatedOn    = "sys_created_on"
+	FieldUpdatedOn    = "sys_updated_on"
+)
+
+// Default credentials of the fake instance.
+const (
+	DefaultUsername     = "admin"
+	DefaultPassword     = "password"
+	DefaultClientID     = "client"
+	DefaultClientSecret = "secret"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+// A Record of a table. Like the Table API, the fake represents every value
+// as a string.
+type Record map[string]string
+
+// A Class of CI, which is also the table its CIs are stored in.
+type Class struct {
+	Name   string
+	Parent string
+
+	// Attributes of CIs of the class, in addition to the attributes of its
+	// parent class.
+	Attributes []string
+}
+
+// A Request received by the fake instance.
+type Request struct {
+	Method   string
+	Path     string
+	RawQuery string
+	Body     string
+}
+
+// An Instance is a fake ServiceNow instance. It implements http.Handler.
+type Instance struct {
+	mu sync.Mutex
+
+	username     string
+	password     string
+	clientID     string
+	clientSecret string
+	tokenTTL     time.Duration
+
+	classes  map[string]*Class
+	tables   map[string]bool              // Tables that are not CI classes.
+	records  map[string]map[string]Record // By table, then sys_id.
+	tokens   map[string]time.Time
+	faults   []*Fault
+	requests []Request
+	nextID   int
+
+	now func() time.Time
+}
+
+// An Option configures an Instance.
+type Option func(i *Instance)
+
+// WithCredentials sets the username and password the instance accepts.
+func WithCredentials(username, password string) Option {
+	return func(i *Instance) {
+		i.username = username
+		i.password = password
+	}
+}
+
+// WithOAuthClient sets the OAuth client the token endpoint accepts.
+func WithOAuthClient(id, secret string) Option {
+	return func(i *Instance) {
+		i.clientID = id
+		i.clientSecret = secret
+	}
+}
+
+// WithTokenTTL sets how long issued access tokens are valid for.
+func WithTokenTTL(d time.Duration) Option {
+	return func(i *Instance) {
+		i.tokenTTL = d
+	}
+}
+
+// WithClass adds a CI class to the instance.
+func WithClass(c Class) Option {
+	return func(i *Instance) {
+		i.addClass(c)
+	}
+}
+
+// WithTable adds a table that is not a CI class to the instance, for
+// example sys_choice.
+func WithTable(name string) Option {
+	return func(i *Instance) {
+		i.tables[name] = true
+	}
+}
+
+// WithClock sets the clock the instance timestamps records with.
+func WithClock(now func() time.Time) Option {
+	return func(i *Instance) {
+		i.now = now
+	}
+}
+
+// DefaultClasses are the CI classes every instance starts with.
+var DefaultClasses = []Class{
+	{Name: "cmdb_ci", Attributes: []string{FieldSysID, FieldSysClassName, FieldName, FieldCreatedOn, FieldUpdatedOn,
+		"short_description", "serial_number", "asset_tag", "operational_status", "install_status", "environment", "owned_by", "discovery_source", "correlation_id"}},
+	{Name: "cmdb_ci_hardware", Parent: "cmdb_ci", Attributes: []string{"manufacturer", "model_id", "ram", "cpu_count", "cpu_type", "disk_space"}},
+	{Name: "cmdb_ci_computer", Parent: "cmdb_ci_hardware", Attributes: []string{"os", "os_version", "os_domain", "host_name", "ip_address", "dns_domain"}},
+	{Name: "cmdb_ci_server", Parent: "cmdb_ci_computer", Attributes: []string{"classification"}},
+	{Name: "cmdb_ci_linux_server", Parent: "cmdb_ci_server", Attributes: []string{"kernel_release"}},
+	{Name: "cmdb_ci_win_server", Parent: "cmdb_ci_server", Attributes: []string{"os_service_pack"}},
+	{Name: "cmdb_ci_appl", Parent: "cmdb_ci", Attributes: []string{"version", "running_process"}},
+	{Name: "cmdb_ci_db_instance", Parent: "cmdb_ci", Attributes: []string{"ip_address", "tcp_port", "version"}},
+	{Name: "cmdb_ci_kubernetes_cluster", Parent: "cmdb_ci", Attributes: []string{"ip_address", "port", "version"}},
+	{Name: "cmdb_ci_kubernetes_node", Parent: "cmdb_ci", Attributes: []string{"ip_address", "kubelet_version", "os_image"}},
+	{Name: "cmdb_ci_kubernetes_namespace", Parent: "cmdb_ci"},
+	{Name: "cmdb_ci_kubernetes_deployment", Parent: "cmdb_ci"},
+	{Name: "cmdb_ci_kubernetes_statefulset", Parent: "cmdb_ci"},
+	{Name: "cmdb_ci_kubernetes_service", Parent: "cmdb_ci", Attributes: []string{"ip_address"}},
+}
+
+// NewInstance returns a fake instance with the DefaultClasses and a release
+// recorded in its system properties.
+func NewInstance(o ...Option) *Instance {
+	i := &Instance{
+		username:     DefaultUsername,
+		password:     DefaultPassword,
+		clientID:     DefaultClientID,
+		clientSecret: DefaultClientSecret,
+		tokenTTL:     30 * time.Minute,
+		classes:      map[string]*Class{},
+		tables:       map[string]bool{},
+		records:      map[string]map[string]Record{},
+		tokens:       map[string]time.Time{},
+		now:          time.Now,
+	}
+	for _, c := range DefaultClasses {
+		i.addClass(c)
+	}
+	for _, fn := range o {
+		fn(i)
+	}
+
+	i.Insert(TableSysProperties, Record{FieldName: "glide.buildname", "value": "Tokyo"})
+	i.Insert(TableSysProperties, Record{FieldName: "glide.buildtag", "value": "glide-tokyo-07-08-2022__patch1-08-10-2022"})
+	return i
+}
+
+// A Server is a fake instance served by an httptest.Server.
+type Server struct {
+	*httptest.Server
+	*Instance
+}
+
+// NewServer starts serving a new fake instance. Callers must close it.
+func NewServer(o ...Option) *Server {
+	i := NewInstance(o...)
+	return &Server{Server: httptest.NewServer(i), Instance: i}
+}
+
+func (i *Instance) addClass(c Class) {
+	i.classes[c.Name] = &c
+}
+
+// isClass returns true if the supplied class is, or extends, the supplied
+// ancestor.
+func (i *Instance) isClass(class, ancestor string) bool {
+	for c := i.classes[class]; c != nil; c = i.classes[c.Parent] {
+		if c.Name == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// attributes returns the attributes of the supplied class, including those
+// it inherits.
+func (i *Instance) attributes(class string) []string {
+	var attrs []string
+	for c := i.classes[class]; c != nil; c = i.classes[c.Parent] {
+		attrs = append(attrs, c.Attributes...)
+	}
+	sort.Strings(attrs)
+	return attrs
+}
+
+func (i *Instance) hasTable(table string) bool {
+	if _, ok := i.classes[table]; ok || i.tables[table] {
+		return true
+	}
+	switch table {
+	case TableSysProperties, TableRelationships, TableChangeRequest, TableSchedule, TableScheduleSpan:
+		return true
+	}
+	return false
+}
+
+func (i *Instance) newSysID() string {
+	i.nextID++
+	return fmt.Sprintf("%032x", i.nextID)
+}
+
+// Insert a record into the supplied table and return its sys_id. Records
+// of a CI class are visible in the tables of its parent classes too.
+func (i *Instance) Insert(table string, values Record) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.insert(table, values)
+}
+
+func (i *Instance) insert(table string, values Record) string {
+	r := Record{}
+	for k, v := range values {
+		r[k] = v
+	}
+	if r[FieldSysID] == "" {
+		r[FieldSysID] = i.newSysID()
+	}
+	now := i.now().UTC().Format(timeFormat)
+	if table == TableChangeRequest {
+		newChangeRequest(r, i.nextID)
+	}
+	r[FieldSysClassName] = table
+	r[FieldCreatedOn] = now
+	r[FieldUpdatedOn] = now
+
+	if i.records[table] == nil {
+		i.records[table] = map[string]Record{}
+	}
+	i.records[table][r[FieldSysID]] = r
+	return r[FieldSysID]
+}
+
+// newChangeRequest numbers a new change request, which is active and awaits
+// approval.
+func newChangeRequest(r Record, id int) {
+	if r["number"] == "" {
+		r["number"] = fmt.Sprintf("CHG%07d", id)
+	}
+	if r["active"] == "" {
+		r["active"] = "true"
+	}
+	if r["state"] == "" {
+		r["state"] = "-5"
+	}
+	if r["approval"] == "" {
+		r["approval"] = "requested"
+	}
+}
+
+// Update the supplied fields of the record with the supplied sys_id, for
+// example to simulate a change made in ServiceNow. It returns false if no
+// such record exists.
+func (i *Instance) Update(sysID string, values Record) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	_, ok := i.update(sysID, values)
+	return ok
+}
+
+// update returns true if any field changed.
+func (i *Instance) update(sysID string, values Record) (changed bool, ok bool) {
+	r, ok := i.get(sysID)
+	if !ok {
+		return false, false
+	}
+	for k, v := range values {
+		if k == FieldSysID || k == FieldSysClassName || r[k] == v {
+			continue
+		}
+		r[k] = v
+		changed = true
+	}
+	if changed {
+		r[FieldUpdatedOn] = i.now().UTC().Format(timeFormat)
+	}
+	return changed, true
+}
+
+// Get a copy of the record with the supplied sys_id.
+func (i *Instance) Get(sysID string) (Record, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	r, ok := i.get(sysID)
+	return copyRecord(r), ok
+}
+
+func (i *Instance) get(sysID string) (Record, bool) {
+	for _, t := range i.records {
+		if r, ok := t[sysID]; ok {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// Delete the record with the supplied sys_id. It returns false if no such
+// record exists.
+func (i *Instance) Delete(sysID string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.delete(sysID)
+}
+
+func (i *Instance) delete(sysID string) bool {
+	for _, t := range i.records {
+		if _, ok := t[sysID]; ok {
+			delete(t, sysID)
+			return true
+		}
+	}
+	return false
+}
+
+// Records returns copies of the records of the supplied table, including
+// those of its child classes, ordered by sys_id.
+func (i *Instance) Records(table string) []Record {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	rs := i.list(table)
+	for n := range rs {
+		rs[n] = copyRecord(rs[n])
+	}
+	return rs
+}
+
+func (i *Instance) list(table string) []Record {
+	var out []Record
+	for t, rs := range i.records {
+		if t != table && !i.isClass(t, table) {
+			continue
+		}
+		for _, r := range rs {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a][FieldSysID] < out[b][FieldSysID] })
+	return out
+}
+
+// Requests returns the requests the instance received, oldest first.
+func (i *Instance) Requests() []Request {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]Request(nil), i.requests...)
+}
+
+// Reset forgets the received requests.
+func (i *Instance) Reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.requests = nil
+}
+
+func copyRecord(r Record) Record {
+	if r == nil {
+		return nil
+	}
+	out := make(Record, len(r))
+	for k, v := range r {
+		out[k] = v
+	}
+	return out
+}
+
+// compile time assertion that an Instance is an http.Handler.
+var _ http.Handler = &Instance{}
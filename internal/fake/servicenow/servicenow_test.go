@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicenow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/provider-cmdb/apis/idenrecon/v1alpha1"
+	"github.com/crossplane/provider-cmdb/internal/clients"
+	"github.com/crossplane/provider-cmdb/internal/clients/idenrecon"
+	"github.com/crossplane/provider-cmdb/internal/clients/meta"
+	"github.com/crossplane/provider-cmdb/internal/clients/table"
+)
+
+func config(s *Server, name string) clients.Config {
+	return clients.Config{BaseURL: s.URL, Username: DefaultUsername, Password: DefaultPassword, ProviderConfigName: name}
+}
+
+func TestQuery(t *testing.T) {
+	records := []Record{
+		{FieldSysID: "1", FieldName: "web-1", "ram": "2048", "os": "Linux"},
+		{FieldSysID: "2", FieldName: "web-2", "ram": "4096", "os": "Windows"},
+		{FieldSysID: "3", FieldName: "db-1", "ram": "8192"},
+	}
+
+	cases := map[string]struct {
+		reason string
+		query  string
+		want   []string
+	}{
+		"Empty": {
+			reason: "An empty query should match every record.",
+			want:   []string{"1", "2", "3"},
+		},
+		"And": {
+			reason: "Conditions joined by ^ should all have to match.",
+			query:  "nameSTARTSWITHweb^ram>2048",
+			want:   []string{"2"},
+		},
+		"Or": {
+			reason: "Conditions joined by ^OR should match if either matches.",
+			query:  "name=db-1^ORos=Linux",
+			want:   []string{"1", "3"},
+		},
+		"NewQuery": {
+			reason: "Queries joined by ^NQ should match if either matches.",
+			query:  "name=web-2^NQram<=2048",
+			want:   []string{"1", "2"},
+		},
+		"InAndEmpty": {
+			reason: "IN and ISEMPTY conditions should be supported.",
+			query:  "nameINweb-1,db-1^osISEMPTY",
+			want:   []string{"3"},
+		},
+		"OrderBy": {
+			reason: "ORDERBYDESC should order the matched records.",
+			query:  "nameLIKE-^ORDERBYDESCram",
+			want:   []string{"3", "2", "1"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			q, err := parseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("parseQuery(...): %v", err)
+			}
+			var got []string
+			var matched []Record
+			for _, r := range records {
+				if q.matches(r) {
+					matched = append(matched, r)
+				}
+			}
+			q.sort(matched)
+			for _, r := range matched {
+				got = append(got, r[FieldSysID])
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nmatches(...): -want, +got:\n%s\n", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSDK(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	cfg := config(s, t.Name())
+	ctx := context.Background()
+
+	ci := &v1alpha1.CIParameters{ClassName: "cmdb_ci_linux_server", Name: "web-1", Values: map[string]string{"ram": "2048"}}
+
+	for _, want := range []string{OperationInsert, OperationNoChange} {
+		res, err := idenrecon.NewIdenReconClient(cfg).CreateIdentifyReconcile(idenrecon.GenerateCIOptions(ctx, ci))
+		if err != nil {
+			t.Fatalf("CreateIdentifyReconcile(...): %v", err)
+		}
+		if got := (*res.Payload.Result.Items)[0].Operation; got != want {
+			t.Errorf("CreateIdentifyReconcile(...): want operation %s, got %s", want, got)
+		}
+	}
+
+	got, err := table.NewTableClient(cfg).GetTableItems(table.GenerateGetTableItemsOptions(ctx, "cmdb_ci_server", "web-1"))
+	if err != nil {
+		t.Fatalf("GetTableItems(...): %v", err)
+	}
+	if len(got.Payload.Result) != 1 || got.Payload.Result[0]["ram"] != "2048" {
+		t.Errorf("GetTableItems(...): want the CI from its parent class table, got %v", got.Payload.Result)
+	}
+
+	m, err := meta.NewMetaClient(cfg).GetCmdbMetaByClassName(meta.GenerateGetMetaOptions(ctx, "cmdb_ci_linux_server"))
+	if err != nil {
+		t.Fatalf("GetCmdbMetaByClassName(...): %v", err)
+	}
+	elements := map[string]bool{}
+	for _, a := range m.Payload.Result.Attributes {
+		elements[a.Element] = true
+	}
+	if !elements["ram"] || !elements[FieldName] || !elements["kernel_release"] {
+		t.Errorf("GetCmdbMetaByClassName(...): want inherited attributes, got %v", elements)
+	}
+
+	_, err = meta.NewMetaClient(cfg).GetCmdbMetaByClassName(meta.GenerateGetMetaOptions(ctx, "cmdb_ci_unknown"))
+	if !clients.IsNotFound(err) {
+		t.Errorf("GetCmdbMetaByClassName(...): want not found error, got %v", err)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	cfg := config(s, t.Name())
+	ctx := context.Background()
+
+	s.InjectFault(Fault{StatusCode: http.StatusUnauthorized, Times: 1, Path: "/api/now/table/"})
+	_, err := table.NewTableClient(cfg).GetTableItems(table.GenerateGetTableItemsOptions(ctx, "cmdb_ci", "web-1"))
+	if !clients.IsUnauthorized(err) {
+		t.Errorf("GetTableItems(...): want unauthorized error, got %v", err)
+	}
+
+	s.InjectFault(HibernatingFault())
+	_, err = table.NewTableClient(cfg).GetTableItems(table.GenerateGetTableItemsOptions(ctx, "cmdb_ci", "web-1"))
+	if e, ok := clients.AsUnavailable(err); !ok || e.Reason != clients.ReasonHibernating {
+		t.Errorf("GetTableItems(...): want hibernating error, got %v", err)
+	}
+}
+
+func TestOAuth(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	res, err := http.PostForm(s.URL+pathToken, url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {DefaultClientID},
+		"client_secret": {DefaultClientSecret},
+		"username":      {DefaultUsername},
+		"password":      {DefaultPassword},
+	})
+	if err != nil {
+		t.Fatalf("PostForm(...): %v", err)
+	}
+	defer res.Body.Close() //nolint:errcheck // Only read by this test.
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("PostForm(...): want status 200, got %d", res.StatusCode)
+	}
+
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		t.Fatalf("Decode(...): %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, s.URL+pathAPI+"/table/"+TableSysProperties, nil)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do(...): %v", err)
+	}
+	defer res.Body.Close() //nolint:errcheck // Only read by this test.
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Do(...): want status 200 with an access token, got %d", res.StatusCode)
+	}
+}